@@ -0,0 +1,177 @@
+package dummyneko
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is a pose of the neko, i.e. an Action without direction and frame.
+type Kind string
+
+const (
+	KindAlert   Kind = "alert"
+	KindStill   Kind = "still"
+	KindYawn    Kind = "yawn"
+	KindItch    Kind = "itch"
+	KindSleep   Kind = "sleep"
+	KindRun     Kind = "run"
+	KindScratch Kind = "scratch"
+	KindPurr    Kind = "purr"
+)
+
+// kindInfo describes the action name format of a Kind.
+type kindInfo struct {
+	kind Kind
+	// directed actions are prefixed with a compass direction.
+	directed bool
+	// framed actions are suffixed with a frame number.
+	framed bool
+}
+
+// kinds are checked in order by ParseAction.
+var kinds = []kindInfo{
+	{kind: KindAlert},
+	{kind: KindStill},
+	{kind: KindYawn},
+	{kind: KindItch, framed: true},
+	{kind: KindSleep, framed: true},
+	{kind: KindRun, directed: true, framed: true},
+	{kind: KindScratch, directed: true, framed: true},
+	{kind: KindPurr, framed: true},
+}
+
+// Pose is a parsed Action.
+type Pose struct {
+	Kind Kind
+	// Direction is a compass direction (see Compass function) of directed kinds such as run and scratch, empty otherwise.
+	Direction string
+	// Frame is the animation frame number starting from 1, or 0 for kinds with a single frame.
+	Frame uint
+}
+
+// lookupKind returns the name format of a known kind.
+func lookupKind(k Kind) (kindInfo, bool) {
+	for _, info := range kinds {
+		if info.kind == k {
+			return info, true
+		}
+	}
+	return kindInfo{}, false
+}
+
+// Action returns the action name of the pose.  The direction is dropped for undirected kinds, and bearings are written like Compass does, e.g. "D045" as "d45".
+func (p Pose) Action() Action {
+	d := canonicalDirection(p.Direction)
+	if info, ok := lookupKind(p.Kind); ok && !info.directed {
+		d = ""
+	}
+	a := strings.ToLower(d) + string(p.Kind)
+	if p.Frame > 0 {
+		a += strconv.FormatUint(uint64(p.Frame), 10)
+	}
+	return Action(a)
+}
+
+// NewAction returns the action name for the given kind, compass direction and frame.
+func NewAction(kind Kind, direction string, frame uint) Action {
+	return Pose{kind, direction, frame}.Action()
+}
+
+// ParseAction breaks the action name into kind, direction and frame.
+func ParseAction(a Action) (Pose, error) {
+	name, digits := splitFrame(string(a))
+
+	for _, info := range kinds {
+		k := info.kind
+		if !strings.HasSuffix(name, string(k)) {
+			continue
+		}
+		d := strings.ToUpper(strings.TrimSuffix(name, string(k)))
+		if info.directed != (d != "") || d != "" && !isCompass(d) {
+			continue
+		}
+		if info.framed != (digits != "") {
+			continue
+		}
+		p := Pose{Kind: k, Direction: d}
+		if digits != "" {
+			f, err := strconv.ParseUint(digits, 10, 0)
+			if err != nil || f == 0 {
+				continue
+			}
+			p.Frame = uint(f)
+		}
+		return p, nil
+	}
+	return Pose{}, fmt.Errorf("unknown action %q", a)
+}
+
+// splitFrame splits the trailing frame number off the action name.
+func splitFrame(s string) (name, digits string) {
+	i := strings.LastIndexFunc(s, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	return s[:i+1], s[i+1:]
+}
+
+// directAction returns the action a of a directed kind given without direction, e.g. "run1", in the compass direction d.  Actions of undirected kinds are returned unchanged.
+func directAction(a Action, d string) Action {
+	if d == "" {
+		return a
+	}
+	name, digits := splitFrame(string(a))
+	if info, ok := lookupKind(Kind(name)); ok && !info.directed {
+		return a
+	}
+	f, err := strconv.ParseUint(digits, 10, 0)
+	if err != nil {
+		return NewAction(Kind(a), d, 0)
+	}
+	return NewAction(Kind(name), d, uint(f))
+}
+
+// canonicalDirection returns the direction d in upper case, with bearings in whole degrees below 360 without leading zeros.
+func canonicalDirection(d string) string {
+	d = strings.ToUpper(d)
+	if !strings.HasPrefix(d, "D") {
+		return d
+	}
+	bearing, err := strconv.ParseUint(d[1:], 10, 0)
+	if err != nil {
+		return d
+	}
+	return "D" + strconv.FormatUint(bearing%360, 10)
+}
+
+// isCompass reports whether d is a direction name returned from Compass function.
+func isCompass(d string) bool {
+	for _, c := range compassPoints {
+		if d == c {
+			return true
+		}
+	}
+	if !strings.HasPrefix(d, "D") {
+		return false
+	}
+	bearing, err := strconv.ParseUint(d[1:], 10, 0)
+	return err == nil && bearing < 360 && strconv.FormatUint(bearing, 10) == d[1:]
+}
+
+// Kind returns the kind of the action, or an empty string for unknown actions.
+func (a Action) Kind() Kind {
+	p, _ := ParseAction(a)
+	return p.Kind
+}
+
+// Direction returns the compass direction of the action, or an empty string for undirected and unknown actions.
+func (a Action) Direction() string {
+	p, _ := ParseAction(a)
+	return p.Direction
+}
+
+// Frame returns the frame number of the action, or zero for single-frame and unknown actions.
+func (a Action) Frame() uint {
+	p, _ := ParseAction(a)
+	return p.Frame
+}
@@ -0,0 +1,107 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestParseAction(t *testing.T) {
+	cases := []struct {
+		a Action
+		p Pose
+	}{
+		{ActionAlert, Pose{Kind: KindAlert}},
+		{ActionStill, Pose{Kind: KindStill}},
+		{ActionYawn, Pose{Kind: KindYawn}},
+		{ActionItch2, Pose{Kind: KindItch, Frame: 2}},
+		{ActionSleep1, Pose{Kind: KindSleep, Frame: 1}},
+		{ActionNERun2, Pose{Kind: KindRun, Direction: "NE", Frame: 2}},
+		{ActionWScratch1, Pose{Kind: KindScratch, Direction: "W", Frame: 1}},
+		{"nnwrun4", Pose{Kind: KindRun, Direction: "NNW", Frame: 4}},
+		{"d150scratch1", Pose{Kind: KindScratch, Direction: "D150", Frame: 1}},
+	}
+	for _, c := range cases {
+		p, err := ParseAction(c.a)
+		if err != nil || p != c.p {
+			t.Errorf("ParseAction(%q) expected %+v, got %+v (%v)", c.a, c.p, p, err)
+		}
+		if a := p.Action(); a != c.a {
+			t.Errorf("%+v.Action() expected %q, got %q", p, c.a, a)
+		}
+		if c.a.Kind() != c.p.Kind || c.a.Direction() != c.p.Direction || c.a.Frame() != c.p.Frame {
+			t.Errorf("%q methods expected %+v, got %q, %q, %d", c.a, c.p, c.a.Kind(), c.a.Direction(), c.a.Frame())
+		}
+	}
+
+	for _, a := range ActionsFor(Options{RunDirections: 16, ScratchDirections: 6}) {
+		p, err := ParseAction(a)
+		if err != nil {
+			t.Errorf("ParseAction(%q) failed: %v", a, err)
+		} else if p.Action() != a {
+			t.Errorf("ParseAction(%q).Action() returned %q", a, p.Action())
+		}
+	}
+}
+
+func TestParseActionErrors(t *testing.T) {
+	for _, a := range []Action{
+		"",
+		"dance1",
+		"still1",
+		"itch",
+		"itch0",
+		"run1",
+		"nrun",
+		"xrun1",
+		"d400run1",
+		"d045run1",
+		"NRUN1",
+	} {
+		if p, err := ParseAction(a); err == nil {
+			t.Errorf("ParseAction(%q) expected error, got %+v", a, p)
+		}
+	}
+}
+
+func TestDirectAction(t *testing.T) {
+	cases := []struct {
+		a Action
+		d string
+		e Action
+	}{
+		{"run1", "NE", "nerun1"},
+		{"scratch2", "w", "wscratch2"},
+		{"run12", "D045", "d45run12"},
+		{"scratch1", "d360", "d0scratch1"},
+		{"alert", "S", "alert"},
+		{"sleep2", "S", "sleep2"},
+		{"run1", "", "run1"},
+	}
+	for _, c := range cases {
+		if v := directAction(c.a, c.d); v != c.e {
+			t.Errorf("directAction(%q, %q): expected %q, got %q", c.a, c.d, c.e, v)
+		}
+	}
+}
+
+func TestNewActionRoundTrip(t *testing.T) {
+	for _, info := range kinds {
+		for _, d := range []string{"", "ne", "S", "D045", "d120", "D360"} {
+			if info.directed && d == "" {
+				continue
+			}
+			var f uint
+			if info.framed {
+				f = 2
+			}
+			a := NewAction(info.kind, d, f)
+			p, err := ParseAction(a)
+			if err != nil {
+				t.Errorf("NewAction(%q, %q, %d) = %q: %v", info.kind, d, f, a, err)
+				continue
+			}
+			if p.Kind != info.kind || p.Frame != f || info.directed != (p.Direction != "") {
+				t.Errorf("NewAction(%q, %q, %d) = %q parsed as %+v", info.kind, d, f, a, p)
+			}
+		}
+	}
+}
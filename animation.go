@@ -0,0 +1,117 @@
+package dummyneko
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultTickDuration is the duration of a tick when Options.TickDuration is not set.
+const DefaultTickDuration = 300 * time.Millisecond
+
+// Frame is a single frame of Animation.
+type Frame struct {
+	Action   Action
+	Duration time.Duration
+}
+
+// Animation is a looping sequence of frames.
+type Animation []Frame
+
+// Animator is implemented by Transitions that render animations rather than single actions.
+type Animator interface {
+	// Animate returns the animation of the state n returned from Render.
+	Animate(State, Pos, Options) Animation
+}
+
+// Animate returns the animation of the state n rendered by s.
+//
+// Animations from Options take precedence over ones returned from Animator.  Transitions that do not implement Animator show the rendered action for a tick.
+func Animate(s Transition, n State, m Pos, b Options) Animation {
+	if p, err := ParseAction(n.Action); err == nil {
+		if a, ok := b.Animations[p.Kind]; ok {
+			return a.direct(p.Direction)
+		}
+	}
+	if s, ok := s.(Animator); ok {
+		return s.Animate(n, m, b)
+	}
+	return Animation{{Action: n.Action, Duration: tickDuration(b)}}
+}
+
+func tickDuration(b Options) time.Duration {
+	if b.TickDuration <= 0 {
+		return DefaultTickDuration
+	}
+	return b.TickDuration
+}
+
+// direct returns a copy of the animation with actions in the compass direction d, or the animation itself for an empty direction.
+func (a Animation) direct(d string) Animation {
+	if d == "" {
+		return a
+	}
+	c := make(Animation, len(a))
+	for i, f := range a {
+		c[i] = Frame{directAction(f.Action, d), f.Duration}
+	}
+	return c
+}
+
+// Duration returns the duration of a single loop of the animation.
+func (a Animation) Duration() time.Duration {
+	var d time.Duration
+	for _, f := range a {
+		d += f.Duration
+	}
+	return d
+}
+
+// At returns the action shown at the elapsed time since the start of the animation, and the time left until the next frame.
+func (a Animation) At(elapsed time.Duration) (Action, time.Duration) {
+	if len(a) == 0 {
+		return "", 0
+	}
+	d := a.Duration()
+	if d <= 0 {
+		return a[0].Action, 0
+	}
+	elapsed %= d
+	for _, f := range a {
+		if elapsed < f.Duration {
+			return f.Action, f.Duration - elapsed
+		}
+		elapsed -= f.Duration
+	}
+	return a[0].Action, a[0].Duration
+}
+
+// Equal reports whether animations have the same frames.
+func (a Animation) Equal(o Animation) bool {
+	if len(a) != len(o) {
+		return false
+	}
+	for i := range a {
+		if a[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Animate implements Animator.  The default animation shows the actions of the state in turn, each for the state's Ticks.
+func (s node) Animate(n State, m Pos, b Options) Animation {
+	def := s.def()
+	prefix := strings.TrimSuffix(string(n.Action), string(def.Actions[s.count%uint(len(def.Actions))]))
+
+	ticks := def.ticks.eval(b)
+	if ticks == 0 {
+		ticks = 1
+	}
+	d := time.Duration(ticks) * tickDuration(b)
+
+	a := make(Animation, len(def.Actions))
+	for i, action := range def.Actions {
+		a[i] = Frame{action, d}
+	}
+	return a.direct(prefix)
+}
@@ -0,0 +1,97 @@
+package dummyneko
+
+import (
+	"testing"
+	"time"
+)
+
+const ms = time.Millisecond
+
+func TestAnimate(t *testing.T) {
+	run := Animation{
+		{"run1", 100 * ms},
+		{"run2", 100 * ms},
+		{"run3", 100 * ms},
+		{"run4", 100 * ms},
+	}
+	sleep := Animation{
+		{ActionSleep1, 1000 * ms},
+		{ActionSleep2, 1000 * ms},
+	}
+
+	cases := []struct {
+		s string
+		m Pos
+		b Options
+		e Animation
+	}{
+		{
+			"still", Pos{}, Options{},
+			Animation{{ActionStill, DefaultTickDuration}},
+		},
+		{
+			"sleep", Pos{}, Options{SleepTicks: 2, TickDuration: 100 * ms},
+			Animation{{ActionSleep1, 200 * ms}, {ActionSleep2, 200 * ms}},
+		},
+		{
+			"sleep", Pos{}, Options{SleepTicks: 2, Animations: map[Kind]Animation{KindSleep: sleep}},
+			sleep,
+		},
+		{
+			"run", Pos{X: 10}, Options{Step: 1},
+			Animation{{ActionERun1, DefaultTickDuration}, {ActionERun2, DefaultTickDuration}},
+		},
+		{
+			"run", Pos{X: 10}, Options{Step: 1, Animations: map[Kind]Animation{KindRun: run}},
+			Animation{{"erun1", 100 * ms}, {"erun2", 100 * ms}, {"erun3", 100 * ms}, {"erun4", 100 * ms}},
+		},
+		{
+			"scratch", Pos{Y: -10}, Options{ScratchTicks: 3, TickDuration: 100 * ms},
+			Animation{{ActionNScratch1, 300 * ms}, {ActionNScratch2, 300 * ms}},
+		},
+	}
+	for _, c := range cases {
+		s := defaultState(c.s)
+		n := s.Render(State{}, c.m, c.b)
+		a := Animate(s, n, c.m, c.b)
+		if !a.Equal(c.e) {
+			t.Errorf("%s expected animation %v, got %v", c.s, c.e, a)
+		}
+	}
+}
+
+func TestAnimationAt(t *testing.T) {
+	a := Animation{
+		{"a", 100 * ms},
+		{"b", 300 * ms},
+	}
+	cases := []struct {
+		elapsed time.Duration
+		e       Action
+		left    time.Duration
+	}{
+		{0, "a", 100 * ms},
+		{99 * ms, "a", 1 * ms},
+		{100 * ms, "b", 300 * ms},
+		{350 * ms, "b", 50 * ms},
+		{400 * ms, "a", 100 * ms},
+		{1250 * ms, "a", 50 * ms},
+	}
+	for _, c := range cases {
+		action, left := a.At(c.elapsed)
+		if action != c.e || left != c.left {
+			t.Errorf("At(%v) expected %q, %v, got %q, %v", c.elapsed, c.e, c.left, action, left)
+		}
+	}
+}
+
+func TestActionsForAnimations(t *testing.T) {
+	b := Options{Animations: map[Kind]Animation{
+		KindRun:   {{"run1", ms}, {"run2", ms}, {"run3", ms}},
+		KindSleep: {{ActionSleep1, ms}, {ActionSleep2, ms}},
+	}}
+	as := ActionsFor(b)
+	if e := len(SupportedActions) + 8; len(as) != e {
+		t.Errorf("expected %d actions, got %d: %v", e, len(as), as)
+	}
+}
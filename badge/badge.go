@@ -0,0 +1,350 @@
+// Package badge renders neko animations as GIF images over HTTP, for places where JavaScript is not allowed, e.g. README files, chat bots and email signatures.
+package badge
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// Query parameter limits.
+const (
+	DefaultTicks = 8
+	MaxTicks     = 200
+	DefaultSize  = 64
+	MaxSize      = 512
+	MaxScale     = 4
+	// MaxPixels limits the total number of pixels of all frames, w*h*scale²*ticks.
+	MaxPixels = 8 << 20
+	// SpriteSize is the width and height of sprites, in pixels.
+	SpriteSize = 32
+)
+
+// DefaultCacheSize is the default total size of cached responses, in bytes.
+const DefaultCacheSize = 32 << 20
+
+// Handler renders an animated GIF of the neko.
+//
+// Query parameters:
+//
+//	action  name of the behavior state to show, defaults to "sleep"
+//	path    pointer waypoints "x,y x,y ..." to chase instead of the action; the pointer moves to the next waypoint once neko catches it
+//	ticks   number of frames, up to MaxTicks
+//	w, h    image size in pixels before scaling, up to MaxSize
+//	scale   nearest-neighbour scale factor, up to MaxScale
+//
+// The frames together have at most MaxPixels pixels.  Neko starts at the center of the image.  Responses depend only on the query, so they are cacheable.
+type Handler struct {
+	Sprites Sprites
+	// Options of the simulation, defaults to neko.DefaultOptions.
+	Options *neko.Options
+	// Behavior of the neko, defaults to neko.DefaultBehavior.
+	Behavior *neko.Behavior
+	// MaxAge of cached responses, defaults to a day.
+	MaxAge time.Duration
+	// CacheSize is the total size of rendered responses kept in memory, in bytes.  Zero means DefaultCacheSize, and negative values disable the cache.
+	CacheSize int64
+
+	once  sync.Once
+	cache *cache
+}
+
+type query struct {
+	action string
+	path   []neko.Pos
+	ticks  int
+	w, h   int
+	scale  int
+}
+
+func parseQuery(r *http.Request) (query, error) {
+	v := r.URL.Query()
+	q := query{
+		action: v.Get("action"),
+		ticks:  DefaultTicks,
+		w:      DefaultSize,
+		h:      DefaultSize,
+		scale:  1,
+	}
+	if q.action == "" {
+		q.action = "sleep"
+	}
+
+	ints := []struct {
+		name     string
+		p        *int
+		min, max int
+	}{
+		{"ticks", &q.ticks, 1, MaxTicks},
+		{"w", &q.w, SpriteSize, MaxSize},
+		{"h", &q.h, SpriteSize, MaxSize},
+		{"scale", &q.scale, 1, MaxScale},
+	}
+	for _, i := range ints {
+		s := v.Get(i.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < i.min || n > i.max {
+			return q, fmt.Errorf("%s must be an integer from %d to %d", i.name, i.min, i.max)
+		}
+		*i.p = n
+	}
+	if q.w*q.h*q.scale*q.scale*q.ticks > MaxPixels {
+		return q, fmt.Errorf("w*h*scale²*ticks must be at most %d", MaxPixels)
+	}
+
+	if s := v.Get("path"); s != "" {
+		for _, p := range strings.Fields(s) {
+			xy := strings.Split(p, ",")
+			if len(xy) != 2 {
+				return q, fmt.Errorf("invalid path point %q", p)
+			}
+			x, errx := strconv.ParseFloat(xy[0], 64)
+			y, erry := strconv.ParseFloat(xy[1], 64)
+			if errx != nil || erry != nil || !finite(x) || !finite(y) {
+				return q, fmt.Errorf("invalid path point %q", p)
+			}
+			q.path = append(q.path, neko.Pos{X: x, Y: y})
+		}
+	}
+	return q, nil
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// key returns the normalized query.  Queries with equal keys render the same image.
+func (q query) key() string {
+	var sb strings.Builder
+	if q.path != nil {
+		sb.WriteString("path=")
+		for i, p := range q.path {
+			if i > 0 {
+				sb.WriteByte('+')
+			}
+			sb.WriteString(strconv.FormatFloat(p.X, 'g', -1, 64) + "," + strconv.FormatFloat(p.Y, 'g', -1, 64))
+		}
+	} else {
+		sb.WriteString("action=" + url.QueryEscape(q.action))
+	}
+	fmt.Fprintf(&sb, "&ticks=%d&w=%d&h=%d&scale=%d", q.ticks, q.w, q.h, q.scale)
+	return sb.String()
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key := q.key()
+	maxAge := h.MaxAge
+	if maxAge == 0 {
+		maxAge = 24 * time.Hour
+	}
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	setCacheHeaders := func() {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		w.Header().Set("ETag", etag)
+	}
+	// The ETag depends only on the query, so cached images are revalidated without rendering.
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		setCacheHeaders()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.once.Do(func() {
+		size := h.CacheSize
+		if size == 0 {
+			size = DefaultCacheSize
+		}
+		if size > 0 {
+			h.cache = newCache(size)
+		}
+	})
+	data, ok := h.cache.get(key)
+	if !ok {
+		g, err := h.render(q)
+		if errors.Is(err, errUnknownState) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = buf.Bytes()
+		h.cache.add(key, data)
+	}
+
+	setCacheHeaders()
+	w.Header().Set("Content-Type", "image/gif")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// etagMatch reports whether the If-None-Match header matches the ETag.
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+var errUnknownState = errors.New("unknown state")
+
+// render simulates the neko and draws its frames.
+func (h *Handler) render(q query) (*gif.GIF, error) {
+	b := neko.DefaultOptions
+	if h.Options != nil {
+		b = *h.Options
+	}
+	bh := &neko.DefaultBehavior
+	if h.Behavior != nil {
+		bh = h.Behavior
+	}
+
+	s, err := neko.NewBehaviorState(bh)
+	if err != nil {
+		return nil, err
+	}
+
+	n := neko.State{
+		X: float64(q.w-SpriteSize) / 2,
+		Y: float64(q.h-SpriteSize) / 2,
+	}
+	m := neko.Pos{X: n.X, Y: n.Y}
+	if q.path != nil {
+		m = q.path[0]
+	} else {
+		s, err = neko.Force(s, q.action, n, m, b)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", errUnknownState, q.action)
+		}
+		n = s.Render(n, m, b)
+	}
+
+	states := make([]neko.State, 0, q.ticks)
+	for i := 0; i < q.ticks; i++ {
+		if len(q.path) > 0 {
+			if len(q.path) > 1 && math.Hypot(m.X-n.X, m.Y-n.Y) <= b.Dmax {
+				q.path = q.path[1:]
+			}
+			m = q.path[0]
+		}
+		if i > 0 || q.path != nil {
+			s = s.Next(n, m, b)
+			n = s.Render(n, m, b)
+		}
+		states = append(states, n)
+	}
+
+	sprites := make(map[neko.Action]image.Image)
+	// in order of appearance, to keep the palette stable
+	var imgs []image.Image
+	for _, n := range states {
+		if _, ok := sprites[n.Action]; ok {
+			continue
+		}
+		img, err := h.Sprites.Sprite(n.Action)
+		if err != nil {
+			return nil, err
+		}
+		sprites[n.Action] = img
+		imgs = append(imgs, img)
+	}
+
+	pal := makePalette(imgs)
+	delay := int(tickDuration(b) / (10 * time.Millisecond))
+	bounds := image.Rect(0, 0, q.w*q.scale, q.h*q.scale)
+	g := &gif.GIF{
+		Config: image.Config{
+			ColorModel: pal,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+	}
+	for _, n := range states {
+		frame := image.NewPaletted(bounds, pal)
+		drawScaled(frame, sprites[n.Action], int(n.X), int(n.Y), q.scale)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, delay)
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+	return g, nil
+}
+
+func tickDuration(b neko.Options) time.Duration {
+	if b.TickDuration <= 0 {
+		return neko.DefaultTickDuration
+	}
+	return b.TickDuration
+}
+
+// makePalette returns a palette with a transparent color followed by the colors of sprites.  If there are too many colors, it falls back to the web-safe palette.
+func makePalette(sprites []image.Image) color.Palette {
+	pal := color.Palette{color.Transparent}
+	seen := make(map[color.RGBA]bool)
+	for _, img := range sprites {
+		r := img.Bounds()
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				if c.A != 0xff || seen[c] {
+					continue
+				}
+				seen[c] = true
+				pal = append(pal, c)
+				if len(pal) > 256 {
+					return append(color.Palette{color.Transparent}, palette.WebSafe...)
+				}
+			}
+		}
+	}
+	return pal
+}
+
+// drawScaled draws the sprite with its top-left corner at (x, y) scaled by the factor.
+func drawScaled(dst *image.Paletted, src image.Image, x, y, scale int) {
+	r := src.Bounds()
+	for sy := r.Min.Y; sy < r.Max.Y; sy++ {
+		for sx := r.Min.X; sx < r.Max.X; sx++ {
+			c := src.At(sx, sy)
+			if _, _, _, a := c.RGBA(); a < 0x8000 {
+				continue
+			}
+			px := (x + sx - r.Min.X) * scale
+			py := (y + sy - r.Min.Y) * scale
+			draw.Draw(dst, image.Rect(px, py, px+scale, py+scale), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package badge
+
+import (
+	"bytes"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// fakeSprites returns solid squares with colors derived from actions and records requested actions.
+type fakeSprites struct {
+	actions []neko.Action
+}
+
+func (s *fakeSprites) Sprite(a neko.Action) (image.Image, error) {
+	s.actions = append(s.actions, a)
+	h := fnv.New32a()
+	h.Write([]byte(a))
+	v := h.Sum32()
+	img := image.NewRGBA(image.Rect(0, 0, SpriteSize, SpriteSize))
+	for y := 8; y < SpriteSize-8; y++ {
+		for x := 8; x < SpriteSize-8; x++ {
+			img.Set(x, y, color.RGBA{uint8(v), uint8(v >> 8), uint8(v >> 16), 0xff})
+		}
+	}
+	return img, nil
+}
+
+func get(t *testing.T, h http.Handler, url string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest("GET", url, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerAction(t *testing.T) {
+	sprites := &fakeSprites{}
+	h := &Handler{Sprites: sprites}
+
+	w := get(t, h, "/?action=sleep&ticks=4&w=40&h=48&scale=2", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/gif" {
+		t.Errorf("content type %q", ct)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 4 {
+		t.Errorf("expected 4 frames, got %d", len(g.Image))
+	}
+	if g.Config.Width != 80 || g.Config.Height != 96 {
+		t.Errorf("expected 80x96 image, got %dx%d", g.Config.Width, g.Config.Height)
+	}
+	if g.LoopCount != 0 {
+		t.Errorf("expected infinite loop, got %d", g.LoopCount)
+	}
+	for _, a := range sprites.actions {
+		if p, err := neko.ParseAction(a); err != nil || p.Kind != neko.KindSleep {
+			t.Errorf("unexpected sprite %q", a)
+		}
+	}
+
+	// Neko is centered: (40-32)/2 = 4 and (48-32)/2 = 8, the square is inset by 8, then scaled.
+	frame := g.Image[0]
+	if _, _, _, a := frame.At(2*(4+8), 2*(8+8)).RGBA(); a == 0 {
+		t.Error("expected sprite at the center")
+	}
+	if _, _, _, a := frame.At(0, 0).RGBA(); a != 0 {
+		t.Error("expected transparent background")
+	}
+}
+
+func TestHandlerPath(t *testing.T) {
+	sprites := &fakeSprites{}
+	h := &Handler{Sprites: sprites}
+
+	w := get(t, h, "/?path=200,16+16,16&ticks=60&w=256", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 60 {
+		t.Errorf("expected 60 frames, got %d", len(g.Image))
+	}
+	dirs := make(map[string]bool)
+	for _, a := range sprites.actions {
+		if p, err := neko.ParseAction(a); err == nil && p.Kind == neko.KindRun {
+			dirs[p.Direction] = true
+		}
+	}
+	if !dirs["E"] || !dirs["W"] {
+		t.Errorf("expected neko to run east and west, got %v", sprites.actions)
+	}
+}
+
+func TestHandlerCache(t *testing.T) {
+	sprites := &fakeSprites{}
+	h := &Handler{Sprites: sprites}
+
+	w := get(t, h, "/?path=10,10+50,50", nil)
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("cache control %q", cc)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag")
+	}
+
+	rendered := len(sprites.actions)
+	again := get(t, h, "/?path=10.0,10+50,50&action=run", nil)
+	if again.Header().Get("ETag") != etag || !bytes.Equal(again.Body.Bytes(), w.Body.Bytes()) {
+		t.Error("expected identical responses for equivalent queries")
+	}
+
+	cached := get(t, h, "/?path=10,10+50,50", http.Header{"If-None-Match": {etag}})
+	if cached.Code != http.StatusNotModified {
+		t.Errorf("expected not modified, got %d", cached.Code)
+	}
+	if len(sprites.actions) != rendered {
+		t.Errorf("expected cached responses without rendering, got %d more sprites", len(sprites.actions)-rendered)
+	}
+
+	h = &Handler{Sprites: sprites, CacheSize: -1}
+	get(t, h, "/?path=10,10+50,50", nil)
+	if len(sprites.actions) == rendered {
+		t.Error("expected rendering with disabled cache")
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := &Handler{Sprites: &fakeSprites{}}
+	for _, url := range []string{
+		"/?action=dance",
+		"/?ticks=0",
+		"/?ticks=1000",
+		"/?w=8",
+		"/?scale=x",
+		"/?path=1,2,3",
+		"/?path=a,b",
+		"/?path=NaN,1",
+		"/?path=1,-Inf",
+		"/?w=512&h=512&scale=4&ticks=200",
+	} {
+		if w := get(t, h, url, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	sprites := &fakeSprites{}
+	f := Fallbacks{Sprites: sprites, Actions: neko.SpriteFallbacks}
+	for _, a := range []neko.Action{neko.ActionPurr1, neko.ActionStill} {
+		if _, err := f.Sprite(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e := []neko.Action{neko.ActionSleep1, neko.ActionStill}; !reflect.DeepEqual(sprites.actions, e) {
+		t.Errorf("expected sprites %v, got %v", e, sprites.actions)
+	}
+}
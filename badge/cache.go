@@ -0,0 +1,69 @@
+package badge
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache keeps recently used responses up to a total size in bytes.
+type cache struct {
+	mu   sync.Mutex
+	max  int64
+	size int64
+	// ll holds entries from the most to the least recently used.
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+func newCache(max int64) *cache {
+	return &cache{
+		max:     max,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached response for the key.  A nil cache is empty.
+func (c *cache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).data, true
+}
+
+// add caches the response, evicting least recently used ones to stay within the size.  Responses larger than the size are not cached, and a nil cache caches nothing.
+func (c *cache) add(key string, data []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if int64(len(data)) > c.max {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		c.size -= int64(len(e.Value.(*cacheEntry).data))
+		c.ll.Remove(e)
+	}
+	c.entries[key] = c.ll.PushFront(&cacheEntry{key, data})
+	c.size += int64(len(data))
+	for c.size > c.max {
+		e := c.ll.Back()
+		old := e.Value.(*cacheEntry)
+		c.ll.Remove(e)
+		delete(c.entries, old.key)
+		c.size -= int64(len(old.data))
+	}
+}
@@ -0,0 +1,35 @@
+package badge
+
+import (
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := newCache(10)
+	c.add("a", make([]byte, 4))
+	c.add("b", make([]byte, 4))
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	// b is the least recently used.
+	c.add("c", make([]byte, 4))
+	cases := []struct {
+		key string
+		e   bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"c", true},
+	}
+	for _, k := range cases {
+		if _, ok := c.get(k.key); ok != k.e {
+			t.Errorf("%s: expected cached %v, got %v", k.key, k.e, ok)
+		}
+	}
+
+	c.add("big", make([]byte, 11))
+	if _, ok := c.get("big"); ok || c.size != 8 {
+		t.Errorf("expected oversized response to be skipped, size %d", c.size)
+	}
+}
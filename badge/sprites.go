@@ -0,0 +1,129 @@
+package badge
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif" // register decoder
+	_ "image/png" // register decoder
+	"net/http"
+	"strings"
+	"sync"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// Sprites provides images of actions.
+type Sprites interface {
+	Sprite(neko.Action) (image.Image, error)
+}
+
+// DefaultSpritesURL is the base URL of sprites used by the wasm host.
+const DefaultSpritesURL = "https://b1nary.tk/ass/webneko.net/socks/"
+
+// Fallbacks provides sprites of some actions with the sprites of other ones, e.g. neko.SpriteFallbacks for sprite sets without purring neko.
+type Fallbacks struct {
+	Sprites
+	Actions map[neko.Action]neko.Action
+}
+
+// Sprite implements Sprites.
+func (f Fallbacks) Sprite(a neko.Action) (image.Image, error) {
+	if b, ok := f.Actions[a]; ok {
+		a = b
+	}
+	return f.Sprites.Sprite(a)
+}
+
+// FileSprites loads sprites named after actions, e.g. "sleep1.gif", from a file system.  Decoded sprites are cached.
+type FileSprites struct {
+	FS http.FileSystem
+	// Ext is the file name extension, defaults to ".gif".
+	Ext string
+
+	mu    sync.Mutex
+	cache map[neko.Action]image.Image
+}
+
+// NewFileSprites returns FileSprites loading sprites from fs.
+func NewFileSprites(fs http.FileSystem) *FileSprites {
+	return &FileSprites{FS: fs}
+}
+
+// Sprite implements Sprites.
+func (s *FileSprites) Sprite(a neko.Action) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if img, ok := s.cache[a]; ok {
+		return img, nil
+	}
+
+	ext := s.Ext
+	if ext == "" {
+		ext = ".gif"
+	}
+	f, err := s.FS.Open("/" + string(a) + ext)
+	if err != nil {
+		return nil, fmt.Errorf("sprite %q: %w", a, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("sprite %q: %w", a, err)
+	}
+
+	if s.cache == nil {
+		s.cache = make(map[neko.Action]image.Image)
+	}
+	s.cache[a] = img
+	return img, nil
+}
+
+// URLSprites fetches sprites named after actions from a base URL, e.g. DefaultSpritesURL.  Fetched sprites are cached.
+type URLSprites struct {
+	Base string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[neko.Action]image.Image
+}
+
+// NewURLSprites returns URLSprites fetching sprites from base.
+func NewURLSprites(base string) *URLSprites {
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return &URLSprites{Base: base}
+}
+
+// Sprite implements Sprites.
+func (s *URLSprites) Sprite(a neko.Action) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if img, ok := s.cache[a]; ok {
+		return img, nil
+	}
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Get(s.Base + string(a) + ".gif")
+	if err != nil {
+		return nil, fmt.Errorf("sprite %q: %w", a, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sprite %q: %s", a, resp.Status)
+	}
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("sprite %q: %w", a, err)
+	}
+
+	if s.cache == nil {
+		s.cache = make(map[neko.Action]image.Image)
+	}
+	s.cache[a] = img
+	return img, nil
+}
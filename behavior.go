@@ -0,0 +1,669 @@
+package dummyneko
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Behavior is a declarative definition of the neko state machine.  It is interpreted by the Transition returned from NewBehaviorState.
+type Behavior struct {
+	// Initial is the name of the first state.
+	Initial string `json:"initial"`
+	// States of the behavior.
+	States []StateDef `json:"states"`
+}
+
+// StateDef defines a single state of Behavior.
+//
+// On each tick, the state first checks pointer edges, i.e. all but done ones, then advances its tick counter.  Every Ticks ticks the state switches to the next action from Actions, and once Count actions were rendered, it takes the first done edge.  Edges whose condition does not hold are skipped.
+type StateDef struct {
+	// Name of the state, unique within the behavior.
+	Name string `json:"name"`
+	// Actions are rendered in turn, one for Ticks ticks.
+	Actions []Action `json:"actions"`
+	// Facing prefixes actions with the compass direction toward the pointer using run or scratch sprite set directions.
+	Facing Facing `json:"facing,omitempty"`
+	// Move makes neko run toward the pointer.
+	Move bool `json:"move,omitempty"`
+	// Rest makes neko recover from fatigue.
+	Rest bool `json:"rest,omitempty"`
+	// Sound is played by hosts on entering the state, see Sound function.
+	Sound string `json:"sound,omitempty"`
+	// Ticks per action.
+	Ticks Param `json:"ticks"`
+	// Count is the number of actions rendered until the state is done.  A state without Count never ends by itself.
+	Count *Param `json:"count,omitempty"`
+	// Edges are transitions to other states.
+	Edges []Edge `json:"edges,omitempty"`
+}
+
+// Facing is a sprite set of directed actions.
+type Facing string
+
+const (
+	FacingNone    Facing = ""
+	FacingRun     Facing = "run"
+	FacingScratch Facing = "scratch"
+)
+
+// directions returns the number of directions in the sprite set.
+func (f Facing) directions(b Options) uint {
+	if f == FacingScratch {
+		return scratchDirections(b)
+	}
+	return runDirections(b)
+}
+
+// Guard is a condition that triggers an Edge.
+type Guard string
+
+const (
+	// GuardNear holds when the pointer is nearby.
+	GuardNear Guard = "near"
+	// GuardFar holds when the pointer is not nearby.
+	GuardFar Guard = "far"
+	// GuardDone holds when the state rendered Count actions.
+	GuardDone Guard = "done"
+	// GuardPet holds when the pointer moves slowly over the sprite, see Options.HitSize and Options.PetSpeed.
+	GuardPet Guard = "pet"
+	// GuardStartle holds when the pointer moves fast over the sprite, see Options.StartleSpeed.
+	GuardStartle Guard = "startle"
+	// GuardLeave holds when the pointer is not over the sprite.
+	GuardLeave Guard = "leave"
+)
+
+// Edge is a transition to another state.
+type Edge struct {
+	On Guard  `json:"on"`
+	To string `json:"to"`
+	// If is an optional condition on Options.  It is either a name of the Options field, negated with "!" for boolean fields, or a comparison "Field=value".  Conditions "tired" and "rested", optionally negated, hold depending on the fatigue of neko, see Options.TiredFatigue and Options.RestedFatigue.
+	If string `json:"if,omitempty"`
+}
+
+// Param is a tick or count parameter given either by Value or by a name of an unsigned integer Options field.
+//
+// In JSON, it is encoded as a number or as a string with the field name.
+type Param struct {
+	Option string
+	Value  uint
+}
+
+func (p Param) String() string {
+	if p.Option != "" {
+		return p.Option
+	}
+	return strconv.FormatUint(uint64(p.Value), 10)
+}
+
+func (p Param) MarshalJSON() ([]byte, error) {
+	if p.Option != "" {
+		return json.Marshal(p.Option)
+	}
+	return json.Marshal(p.Value)
+}
+
+func (p *Param) UnmarshalJSON(data []byte) error {
+	*p = Param{}
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &p.Option)
+	}
+	return json.Unmarshal(data, &p.Value)
+}
+
+// count returns a Param with the given value, for use as StateDef.Count.
+func count(v uint) *Param {
+	return &Param{Value: v}
+}
+
+// DefaultBehavior is the built-in behavior used by NewInitialState.
+var DefaultBehavior = Behavior{
+	Initial: "still",
+	States: []StateDef{
+		{
+			Name:    "still",
+			Actions: []Action{ActionStill},
+			Ticks:   Param{Option: "StillTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert"},
+				{On: GuardDone, To: "yawn", If: "tired"},
+				{On: GuardDone, To: "itch", If: "StillTransition=1"},
+				{On: GuardDone, To: "scratch", If: "StillTransition=2"},
+				{On: GuardDone, To: "itch", If: "rested"},
+				{On: GuardDone, To: "yawn"},
+			},
+		},
+		{
+			Name:    "itch",
+			Actions: []Action{ActionItch1, ActionItch2},
+			Ticks:   Param{Option: "ItchTicks"},
+			Count:   &Param{Option: "ItchCount"},
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert"},
+				{On: GuardDone, To: "postitch"},
+			},
+		},
+		{
+			Name:    "postitch",
+			Actions: []Action{ActionStill},
+			Ticks:   Param{Option: "PostItchTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert"},
+				{On: GuardDone, To: "yawn"},
+			},
+		},
+		{
+			Name:    "scratch",
+			Actions: []Action{"scratch1", "scratch2"},
+			Facing:  FacingScratch,
+			Ticks:   Param{Option: "ScratchTicks"},
+			Count:   &Param{Option: "ScratchCount"},
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert", If: "!ScratchDisableAlert"},
+				{On: GuardDone, To: "postscratch"},
+			},
+		},
+		{
+			Name:    "postscratch",
+			Actions: []Action{ActionStill},
+			Ticks:   Param{Option: "PostScratchTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert"},
+				{On: GuardDone, To: "yawn"},
+			},
+		},
+		{
+			Name:    "yawn",
+			Actions: []Action{ActionYawn},
+			Ticks:   Param{Option: "YawnTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert", If: "!tired"},
+				{On: GuardDone, To: "postyawn"},
+			},
+		},
+		{
+			Name:    "postyawn",
+			Actions: []Action{ActionStill},
+			Ticks:   Param{Option: "PostYawnTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert", If: "!tired"},
+				{On: GuardDone, To: "sleep"},
+			},
+		},
+		{
+			Name:    "sleep",
+			Actions: []Action{ActionSleep1, ActionSleep2},
+			Rest:    true,
+			Ticks:   Param{Option: "SleepTicks"},
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardPet, To: "purr"},
+				{On: GuardFar, To: "alert", If: "!tired"},
+			},
+		},
+		{
+			Name:    "alert",
+			Actions: []Action{ActionAlert},
+			Ticks:   Param{Option: "AlertTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardNear, To: "still"},
+				{On: GuardDone, To: "yawn", If: "tired"},
+				{On: GuardDone, To: "run"},
+			},
+		},
+		{
+			Name:    "run",
+			Actions: []Action{"run1", "run2"},
+			Facing:  FacingRun,
+			Move:    true,
+			Ticks:   Param{Option: "RunTicks"},
+			Edges: []Edge{
+				{On: GuardNear, To: "still"},
+				{On: GuardFar, To: "yawn", If: "tired"},
+			},
+		},
+		{
+			Name:    "purr",
+			Actions: []Action{ActionPurr1, ActionPurr2},
+			Rest:    true,
+			Sound:   "purr",
+			Ticks:   Param{Option: "PurrTicks"},
+			Count:   &Param{Option: "PurrCount"},
+			Edges: []Edge{
+				{On: GuardStartle, To: "startled"},
+				{On: GuardLeave, To: "still"},
+				{On: GuardDone, To: "sleep"},
+			},
+		},
+		{
+			Name:    "startled",
+			Actions: []Action{ActionAlert},
+			Sound:   "startle",
+			Ticks:   Param{Option: "AlertTicks"},
+			Count:   count(1),
+			Edges: []Edge{
+				{On: GuardDone, To: "still"},
+			},
+		},
+	},
+}
+
+// LoadBehavior reads a JSON-encoded Behavior from r and validates it.
+func LoadBehavior(r io.Reader) (*Behavior, error) {
+	var bh Behavior
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&bh); err != nil {
+		return nil, err
+	}
+	if _, err := compileBehavior(&bh); err != nil {
+		return nil, err
+	}
+	return &bh, nil
+}
+
+// Validate checks that the behavior can be interpreted.
+func (bh *Behavior) Validate() error {
+	_, err := compileBehavior(bh)
+	return err
+}
+
+// NewBehaviorState returns the initial Transition of the behavior.  Like the one returned from NewInitialState, it renders nothing and moves to the Initial state on the next tick.
+func NewBehaviorState(bh *Behavior) (Transition, error) {
+	m, err := compileBehavior(bh)
+	if err != nil {
+		return nil, err
+	}
+	return behaviorStart{m}, nil
+}
+
+// machine is a compiled Behavior.
+type machine struct {
+	initial int
+	states  []compiledState
+}
+
+type compiledState struct {
+	StateDef
+	ticks param
+	count *param
+	edges []compiledEdge
+}
+
+type compiledEdge struct {
+	on   Guard
+	to   int
+	cond cond
+}
+
+// param is a compiled Param.
+type param struct {
+	field []int
+	value uint
+}
+
+func (p param) eval(b Options) uint {
+	if p.field == nil {
+		return p.value
+	}
+	return uint(reflect.ValueOf(b).FieldByIndex(p.field).Uint())
+}
+
+// cond is a compiled Edge condition.
+type cond struct {
+	field []int
+	value interface{}
+
+	fatigue fatigueCond
+	negate  bool
+}
+
+func (c cond) eval(b Options, mem memory) bool {
+	if c.fatigue != fatigueNone {
+		return c.fatigue.eval(b, mem) != c.negate
+	}
+	if c.field == nil {
+		return true
+	}
+	return reflect.ValueOf(b).FieldByIndex(c.field).Interface() == c.value
+}
+
+// bounds reports whether the condition may hold with the given Options, and whether it always holds.
+func (c cond) bounds(b Options) (can, must bool) {
+	if c.fatigue == fatigueNone {
+		v := c.eval(b, memory{})
+		return v, v
+	}
+	can, must = c.fatigue.bounds(b)
+	if c.negate {
+		can, must = !must, !can
+	}
+	return can, must
+}
+
+// static reports whether the condition depends only on Options.
+func (c cond) static() bool {
+	return c.fatigue == fatigueNone
+}
+
+var optionsType = reflect.TypeOf(Options{})
+
+func compileParam(p Param) (param, error) {
+	if p.Option == "" {
+		return param{value: p.Value}, nil
+	}
+	f, ok := optionsType.FieldByName(p.Option)
+	if !ok {
+		return param{}, fmt.Errorf("unknown option %q", p.Option)
+	}
+	switch f.Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return param{}, fmt.Errorf("option %q is not an unsigned integer", p.Option)
+	}
+	return param{field: f.Index}, nil
+}
+
+func compileCond(s string) (cond, error) {
+	if s == "" {
+		return cond{}, nil
+	}
+	name, value := s, "true"
+	if strings.HasPrefix(s, "!") {
+		name, value = s[1:], "false"
+	} else if i := strings.IndexByte(s, '='); i >= 0 {
+		name, value = s[:i], s[i+1:]
+	}
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+	switch fc := fatigueCond(name); fc {
+	case fatigueTired, fatigueRested:
+		if value != "true" && value != "false" {
+			return cond{}, fmt.Errorf("condition %q: %q is not a boolean", s, name)
+		}
+		return cond{fatigue: fc, negate: value == "false"}, nil
+	}
+
+	f, ok := optionsType.FieldByName(name)
+	if !ok {
+		return cond{}, fmt.Errorf("condition %q: unknown option %q", s, name)
+	}
+	if value == "true" || value == "false" {
+		if f.Type.Kind() != reflect.Bool {
+			return cond{}, fmt.Errorf("condition %q: option %q is not a boolean", s, name)
+		}
+	}
+
+	var v interface{}
+	var err error
+	switch f.Type.Kind() {
+	case reflect.Bool:
+		v, err = strconv.ParseBool(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(value, 10, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(value, 64)
+	case reflect.String:
+		v = value
+	default:
+		return cond{}, fmt.Errorf("condition %q: option %q is not comparable", s, name)
+	}
+	if err != nil {
+		return cond{}, fmt.Errorf("condition %q: %v", s, err)
+	}
+	return cond{
+		field: f.Index,
+		value: reflect.ValueOf(v).Convert(f.Type).Interface(),
+	}, nil
+}
+
+func compileBehavior(bh *Behavior) (*machine, error) {
+	index := make(map[string]int, len(bh.States))
+	for i, s := range bh.States {
+		if s.Name == "" {
+			return nil, fmt.Errorf("state %d has no name", i)
+		}
+		if _, ok := index[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate state %q", s.Name)
+		}
+		index[s.Name] = i
+	}
+
+	initial, ok := index[bh.Initial]
+	if !ok {
+		return nil, fmt.Errorf("unknown initial state %q", bh.Initial)
+	}
+
+	m := &machine{
+		initial: initial,
+		states:  make([]compiledState, len(bh.States)),
+	}
+	for i := range bh.States {
+		s := &bh.States[i]
+		if len(s.Actions) == 0 {
+			return nil, fmt.Errorf("state %q has no actions", s.Name)
+		}
+		switch s.Facing {
+		case FacingNone, FacingRun, FacingScratch:
+		default:
+			return nil, fmt.Errorf("state %q: unknown facing %q", s.Name, s.Facing)
+		}
+
+		c := compiledState{StateDef: *s}
+		var err error
+		if c.ticks, err = compileParam(s.Ticks); err != nil {
+			return nil, fmt.Errorf("state %q: ticks: %v", s.Name, err)
+		}
+		if s.Count != nil {
+			p, err := compileParam(*s.Count)
+			if err != nil {
+				return nil, fmt.Errorf("state %q: count: %v", s.Name, err)
+			}
+			c.count = &p
+		}
+		for _, e := range s.Edges {
+			switch e.On {
+			case GuardNear, GuardFar, GuardDone, GuardPet, GuardStartle, GuardLeave:
+			default:
+				return nil, fmt.Errorf("state %q: unknown guard %q", s.Name, e.On)
+			}
+			to, ok := index[e.To]
+			if !ok {
+				return nil, fmt.Errorf("state %q: unknown successor %q", s.Name, e.To)
+			}
+			cd, err := compileCond(e.If)
+			if err != nil {
+				return nil, fmt.Errorf("state %q: %v", s.Name, err)
+			}
+			c.edges = append(c.edges, compiledEdge{on: e.On, to: to, cond: cd})
+		}
+		m.states[i] = c
+	}
+	return m, nil
+}
+
+// enter returns a fresh node of the i-th state with the given memory.
+func (m *machine) enter(i int, n State, p Pos, b Options, mem memory) Transition {
+	s := node{m: m, state: i, mem: mem, entered: true}
+	if m.states[i].Move {
+		s = s.accelerate(n, p, b)
+	}
+	return s
+}
+
+func (m *machine) lookup(name string) (int, bool) {
+	for i := range m.states {
+		if m.states[i].Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// StateName returns the name of the behavior state of s, or an empty string if s is not a behavior state or has not entered the initial state yet.
+func StateName(s Transition) string {
+	if t, ok := s.(wrapper); ok {
+		return StateName(t.unwrap())
+	}
+	if s, ok := s.(node); ok {
+		return s.def().Name
+	}
+	return ""
+}
+
+// Moving reports whether s is a behavior state that makes neko run.
+func Moving(s Transition) bool {
+	if t, ok := s.(wrapper); ok {
+		return Moving(t.unwrap())
+	}
+	if s, ok := s.(node); ok {
+		return s.def().Move
+	}
+	return false
+}
+
+// Force returns a fresh Transition of the named state from the behavior of s.  It fails if s was not returned from NewInitialState, NewBehaviorState or a Transition derived from them, or if there is no such state.  Wrapped Transitions, e.g. ones of StatsCollector, stay wrapped.
+func Force(s Transition, name string, n State, m Pos, b Options) (Transition, error) {
+	var mc *machine
+	var mem memory
+	switch s := s.(type) {
+	case node:
+		mc, mem = s.m, s.mem
+	case behaviorStart:
+		mc = s.m
+	case wrapper:
+		t, err := Force(s.unwrap(), name, n, m, b)
+		if err != nil {
+			return nil, err
+		}
+		return s.rewrap(t), nil
+	default:
+		return nil, fmt.Errorf("%T is not a behavior state", s)
+	}
+	i, ok := mc.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown state %q", name)
+	}
+	return mc.enter(i, n, m, b, mem), nil
+}
+
+// wrapper is implemented by Transitions that wrap other ones to observe them.
+type wrapper interface {
+	unwrap() Transition
+	rewrap(Transition) Transition
+}
+
+type behaviorStart struct {
+	m *machine
+}
+
+func (s behaviorStart) Next(n State, m Pos, b Options) Transition {
+	return s.m.enter(s.m.initial, n, m, b, memory{})
+}
+
+func (s behaviorStart) Render(n State, m Pos, b Options) State {
+	return n
+}
+
+// node is a Transition interpreting a single state of the machine.
+type node struct {
+	m     *machine
+	state int
+	tick  uint
+	count uint
+	speed float64
+	mem   memory
+	// path is planned on Next for Render of the same tick.
+	path *plannedPath
+	// entered is set on the tick the state was entered.
+	entered bool
+}
+
+func (s node) def() *compiledState {
+	return &s.m.states[s.state]
+}
+
+func (s node) accelerate(n State, m Pos, b Options) node {
+	a := Pos{n.X, n.Y}
+	s.path = s.path.plan(a, m, b)
+	d := pathLength(a, s.path.path)
+	s.speed = runSpeed(s.speed, d, tired(b, s.mem.fatigue))
+	return s
+}
+
+func (s node) Next(n State, m Pos, b Options) Transition {
+	def := s.def()
+	s.entered = false
+	s.mem.fatigue = tire(s.mem.fatigue, def.Move, def.Rest, b)
+	s.mem.exhausted = exhaust(s.mem.exhausted, s.mem.fatigue, b)
+	speed := s.mem.track(m)
+
+	for _, e := range def.edges {
+		if e.on != GuardDone && pointerGuard(e.on, n, m, speed, b) && e.cond.eval(b, s.mem) {
+			return s.m.enter(e.to, n, m, b, s.mem)
+		}
+	}
+
+	if def.Move {
+		s = s.accelerate(n, m, b)
+	}
+
+	s.tick += 1
+	if s.tick >= def.ticks.eval(b) {
+		s.tick = 0
+		s.count += 1
+	}
+	if def.count != nil && s.count >= def.count.eval(b) {
+		for _, e := range def.edges {
+			if e.on == GuardDone && e.cond.eval(b, s.mem) {
+				return s.m.enter(e.to, n, m, b, s.mem)
+			}
+		}
+	}
+	return s
+}
+
+func (s node) Render(n State, m Pos, b Options) State {
+	def := s.def()
+
+	a := def.Actions[s.count%uint(len(def.Actions))]
+	path := []Pos{m}
+	if def.Move {
+		path = s.path.plan(Pos{n.X, n.Y}, m, b).path
+	}
+	if def.Facing != FacingNone {
+		d := facing(b.Coordinates, def.Facing.directions(b), n.X, n.Y, path[0].X, path[0].Y)
+		a = directAction(a, string(d))
+	}
+	n.Action = a
+
+	if def.Move {
+		followPath(&n, path, s.speed)
+	}
+	return n
+}
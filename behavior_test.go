@@ -0,0 +1,189 @@
+package dummyneko
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// defaultState returns a fresh node of the named DefaultBehavior state.
+func defaultState(name string) Transition {
+	i, ok := defaultMachine.lookup(name)
+	if !ok {
+		panic("unknown state " + name)
+	}
+	return node{m: defaultMachine, state: i}
+}
+
+func TestLoadBehavior(t *testing.T) {
+	f, err := os.Open("testdata/default.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	bh, err := LoadBehavior(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*bh, DefaultBehavior) {
+		t.Errorf("testdata/default.json differs from DefaultBehavior")
+	}
+}
+
+func TestParamJSON(t *testing.T) {
+	cases := []struct {
+		p Param
+		s string
+	}{
+		{Param{Value: 3}, `3`},
+		{Param{Option: "StillTicks"}, `"StillTicks"`},
+	}
+	for _, c := range cases {
+		b, err := json.Marshal(c.p)
+		if err != nil || string(b) != c.s {
+			t.Errorf("json.Marshal(%#v) expected %s, got %s (%v)", c.p, c.s, b, err)
+		}
+		var p Param
+		if err := json.Unmarshal([]byte(c.s), &p); err != nil || p != c.p {
+			t.Errorf("json.Unmarshal(%s) expected %#v, got %#v (%v)", c.s, c.p, p, err)
+		}
+	}
+}
+
+func TestBehaviorErrors(t *testing.T) {
+	cases := []struct {
+		json string
+		err  string
+	}{
+		{
+			`{"initial": "nope", "states": []}`,
+			`unknown initial state "nope"`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1}, {"name": "a", "actions": ["still"], "ticks": 1}]}`,
+			`duplicate state "a"`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "ticks": 1}]}`,
+			`state "a" has no actions`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": "NoSuchTicks"}]}`,
+			`state "a": ticks: unknown option "NoSuchTicks"`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": "Step"}]}`,
+			`state "a": ticks: option "Step" is not an unsigned integer`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1, "edges": [{"on": "far", "to": "b"}]}]}`,
+			`state "a": unknown successor "b"`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1, "edges": [{"on": "never", "to": "a"}]}]}`,
+			`state "a": unknown guard "never"`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1, "edges": [{"on": "far", "to": "a", "if": "!StillTicks"}]}]}`,
+			`state "a": condition "!StillTicks": option "StillTicks" is not a boolean`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1, "edges": [{"on": "far", "to": "a", "if": "StillTicks=x"}]}]}`,
+			`state "a": condition "StillTicks=x": strconv.ParseUint: parsing "x": invalid syntax`,
+		},
+		{
+			`{"initial": "a", "states": [{"name": "a", "actions": ["still"], "ticks": 1, "facing": "up"}]}`,
+			`state "a": unknown facing "up"`,
+		},
+	}
+	for _, c := range cases {
+		_, err := LoadBehavior(strings.NewReader(c.json))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("LoadBehavior(%s) expected error %q, got %v", c.json, c.err, err)
+		}
+	}
+}
+
+func TestCustomBehavior(t *testing.T) {
+	// Neko that washes itself three times when the pointer is far and then runs.
+	bh, err := LoadBehavior(strings.NewReader(`{
+		"initial": "still",
+		"states": [
+			{"name": "still", "actions": ["still"], "ticks": 1, "edges": [
+				{"on": "far", "to": "wash"}
+			]},
+			{"name": "wash", "actions": ["itch1", "itch2"], "ticks": 1, "count": 3, "edges": [
+				{"on": "near", "to": "still"},
+				{"on": "done", "to": "run", "if": "Step=5"},
+				{"on": "done", "to": "still"}
+			]},
+			{"name": "run", "actions": ["run1", "run2"], "facing": "run", "move": true, "ticks": "RunTicks", "edges": [
+				{"on": "near", "to": "still"}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewBehaviorState(bh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := Options{Step: 5, Dmax: 3}
+	m := Pos{X: 12}
+	e := []State{
+		{Action: ActionStill},
+		{Action: ActionItch1},
+		{Action: ActionItch2},
+		{Action: ActionItch1},
+		{X: 5, Action: ActionERun1},
+		{X: 10, Action: ActionERun2},
+		{X: 10, Action: ActionStill},
+	}
+
+	var n State
+	for _, e := range e {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if n != e {
+			t.Errorf("expected %#v, got %#v", e, n)
+		}
+	}
+}
+
+func TestForce(t *testing.T) {
+	var n State
+	var m Pos
+	b := Options{SleepTicks: 1}
+
+	s := NewInitialState()
+	if name := StateName(s); name != "" {
+		t.Errorf("initial state has name %q", name)
+	}
+	s, err := Force(s, "sleep", n, m, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := StateName(s); name != "sleep" {
+		t.Errorf("expected sleep state, got %q", name)
+	}
+	s = s.Next(n, m, b)
+	if n = s.Render(n, m, b); n.Action != ActionSleep2 {
+		t.Errorf("expected %q, got %q", ActionSleep2, n.Action)
+	}
+
+	if _, err := Force(s, "dance", n, m, b); err == nil || err.Error() != `unknown state "dance"` {
+		t.Errorf("expected unknown state error, got %v", err)
+	}
+	if _, err := Force(nil, "sleep", n, m, b); err == nil {
+		t.Errorf("expected error for nil Transition")
+	}
+	if Moving(s) || !Moving(defaultState("run")) {
+		t.Errorf("Moving returned wrong results")
+	}
+}
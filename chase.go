@@ -0,0 +1,158 @@
+package dummyneko
+
+import (
+	"math"
+)
+
+// Target is a candidate pointer to chase, e.g. a remote cursor in a collaborative tool.
+type Target struct {
+	// ID identifies the target between ticks.
+	ID  string
+	Pos Pos
+}
+
+// Strategy selects which target neko chases.
+type Strategy uint
+
+const (
+	// StrategyNearest chases the nearest target.
+	StrategyNearest Strategy = iota
+	// StrategyRecent chases the most recently moved target.
+	StrategyRecent
+	// StrategySticky chases a target until it is caught, then picks the nearest of other ones.
+	StrategySticky
+	// StrategySpeed prefers fast moving targets over near ones.
+	StrategySpeed
+)
+
+// speedSmoothing is the weight of the last tick in the smoothed target speed.
+const speedSmoothing = 0.5
+
+// Chase drives a Transition with the position of a target selected from several ones.
+//
+// Switching targets while neko runs goes through a brief Alert state, the one named by AlertState in the behavior.
+type Chase struct {
+	Strategy Strategy
+	// AlertState is the state forced on target switch while running, defaults to "alert".
+	AlertState string
+
+	s      Transition
+	target string
+	tick   uint
+	// per target history
+	last  map[string]Pos
+	moved map[string]uint
+	speed map[string]float64
+}
+
+// NewChase returns a Chase that drives s.
+func NewChase(s Transition, strategy Strategy) *Chase {
+	return &Chase{
+		Strategy: strategy,
+		s:        s,
+		last:     make(map[string]Pos),
+		moved:    make(map[string]uint),
+		speed:    make(map[string]float64),
+	}
+}
+
+// Transition returns the current Transition.
+func (c *Chase) Transition() Transition {
+	return c.s
+}
+
+// Target returns the ID of the chased target.
+func (c *Chase) Target() string {
+	return c.target
+}
+
+// Tick advances the neko state n chasing one of the targets, and returns the rendered state and the ID of the chased target.  Without targets, neko stays where it is.
+func (c *Chase) Tick(n State, ts []Target, b Options) (State, string) {
+	c.tick += 1
+	c.track(ts)
+
+	if len(ts) == 0 {
+		c.target = ""
+		m := Pos{n.X, n.Y}
+		c.s = c.s.Next(n, m, b)
+		return c.s.Render(n, m, b), ""
+	}
+
+	t := c.selectTarget(n, ts, b)
+	if c.target != "" && t.ID != c.target && Moving(c.s) {
+		alert := c.AlertState
+		if alert == "" {
+			alert = "alert"
+		}
+		if s, err := Force(c.s, alert, n, t.Pos, b); err == nil {
+			c.s = s
+			c.target = t.ID
+			return c.s.Render(n, t.Pos, b), c.target
+		}
+	}
+	c.target = t.ID
+
+	c.s = c.s.Next(n, t.Pos, b)
+	return c.s.Render(n, t.Pos, b), c.target
+}
+
+// track updates per target history and forgets targets that are gone.
+func (c *Chase) track(ts []Target) {
+	present := make(map[string]bool, len(ts))
+	for _, t := range ts {
+		present[t.ID] = true
+		last, ok := c.last[t.ID]
+		if !ok {
+			c.moved[t.ID] = c.tick
+			c.last[t.ID] = t.Pos
+			continue
+		}
+		d := distance(last, t.Pos)
+		if d > 0 {
+			c.moved[t.ID] = c.tick
+		}
+		c.speed[t.ID] = speedSmoothing*d + (1-speedSmoothing)*c.speed[t.ID]
+		c.last[t.ID] = t.Pos
+	}
+	for id := range c.last {
+		if !present[id] {
+			delete(c.last, id)
+			delete(c.moved, id)
+			delete(c.speed, id)
+		}
+	}
+}
+
+func (c *Chase) selectTarget(n State, ts []Target, b Options) Target {
+	a := Pos{n.X, n.Y}
+	best := ts[0]
+	bestScore := math.Inf(-1)
+	for _, t := range ts {
+		d := distance(a, t.Pos)
+		var score float64
+		switch c.Strategy {
+		case StrategyRecent:
+			// Ties are broken by distance.
+			score = float64(c.moved[t.ID]) - d/(d+1)
+		case StrategySticky:
+			if t.ID == c.target {
+				if !pointerNearby(n, t.Pos, b) {
+					return t
+				}
+				if len(ts) > 1 {
+					// Caught, pick another one.
+					continue
+				}
+			}
+			score = -d
+		case StrategySpeed:
+			score = (1 + c.speed[t.ID]) / (1 + d)
+		default:
+			score = -d
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,117 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestChaseStrategies(t *testing.T) {
+	b := Options{Step: 1, Dmax: 1}
+	a := Target{ID: "a", Pos: Pos{X: 10}}
+	c := Target{ID: "c", Pos: Pos{X: -20}}
+
+	cases := []struct {
+		strategy Strategy
+		ticks    [][]Target
+		e        []string
+	}{
+		{
+			StrategyNearest,
+			[][]Target{{a, c}, {a, c}},
+			[]string{"a", "a"},
+		},
+		{
+			StrategyRecent,
+			[][]Target{
+				{a, c},
+				{a, {ID: "c", Pos: Pos{X: -21}}},
+				{a, {ID: "c", Pos: Pos{X: -21}}},
+				{{ID: "a", Pos: Pos{X: 11}}, {ID: "c", Pos: Pos{X: -21}}},
+			},
+			[]string{"a", "c", "c", "a"},
+		},
+		{
+			StrategySticky,
+			[][]Target{
+				{c},
+				{a, c},
+				{a, c},
+			},
+			[]string{"c", "c", "c"},
+		},
+		{
+			StrategySpeed,
+			[][]Target{
+				{a, c},
+				{a, {ID: "c", Pos: Pos{X: -40}}},
+				{a, {ID: "c", Pos: Pos{X: -60}}},
+			},
+			[]string{"a", "c", "c"},
+		},
+		{ // targets are gone
+			StrategyNearest,
+			[][]Target{{a}, {}},
+			[]string{"a", ""},
+		},
+	}
+
+	for _, tc := range cases {
+		ch := NewChase(NewInitialState(), tc.strategy)
+		var n State
+		for i, ts := range tc.ticks {
+			var id string
+			n, id = ch.Tick(n, ts, b)
+			if id != tc.e[i] {
+				t.Errorf("strategy %d tick %d expected target %q, got %q", tc.strategy, i, tc.e[i], id)
+			}
+		}
+	}
+}
+
+func TestChaseSticky(t *testing.T) {
+	b := Options{Step: 5, Dmax: 2}
+	ts := []Target{
+		{ID: "a", Pos: Pos{X: 10}},
+		{ID: "c", Pos: Pos{X: -12}},
+	}
+
+	ch := NewChase(NewInitialState(), StrategySticky)
+	var n State
+	target := ""
+	for i := 0; i < 6; i++ {
+		p := n
+		var id string
+		n, id = ch.Tick(n, ts, b)
+		if target != "" && id != target {
+			for _, t0 := range ts {
+				if t0.ID == target && !pointerNearby(p, t0.Pos, b) {
+					t.Errorf("tick %d: switched from %q to %q before catching it", i, target, id)
+				}
+			}
+		}
+		target = id
+	}
+	if target != "c" {
+		t.Errorf("expected to chase c after catching a, got %q", target)
+	}
+}
+
+func TestChaseSwitchAlerts(t *testing.T) {
+	b := Options{Step: 1, Dmax: 1, AlertTicks: 2}
+	a := Target{ID: "a", Pos: Pos{X: 100}}
+	c := Target{ID: "c", Pos: Pos{X: -100}}
+
+	ch := NewChase(NewInitialState(), StrategyRecent)
+	var n State
+	for i := 0; i < 5; i++ {
+		n, _ = ch.Tick(n, []Target{a}, b)
+	}
+	if StateName(ch.Transition()) != "run" {
+		t.Fatalf("expected neko to run, got %q", StateName(ch.Transition()))
+	}
+
+	x := n.X
+	n, id := ch.Tick(n, []Target{a, c}, b)
+	if id != "c" || n.Action != ActionAlert || n.X != x {
+		t.Errorf("expected alert on switch to c, got %q chasing %q at %f", n.Action, id, n.X)
+	}
+}
@@ -0,0 +1,41 @@
+// Command nekobadge serves animated GIF badges of the neko, e.g. for README files and email signatures.
+//
+// Sprites are fetched from a base URL or loaded from a directory of GIF files named after actions.
+//
+// Usage:
+//
+//	nekobadge [-addr :8080] [-sprites url|dir]
+//
+// Then embed the badge, e.g. http://localhost:8080/?action=sleep or http://localhost:8080/?path=10,30+120,30&ticks=40&w=160.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/badge"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen `address`")
+	src := flag.String("sprites", badge.DefaultSpritesURL, "base `URL or directory` of sprites")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("nekobadge: ")
+
+	var sprites badge.Sprites
+	if strings.HasPrefix(*src, "http://") || strings.HasPrefix(*src, "https://") {
+		sprites = badge.NewURLSprites(*src)
+	} else {
+		sprites = badge.NewFileSprites(http.Dir(*src))
+	}
+	if *src == badge.DefaultSpritesURL {
+		sprites = badge.Fallbacks{Sprites: sprites, Actions: neko.SpriteFallbacks}
+	}
+
+	log.Fatal(http.ListenAndServe(*addr, &badge.Handler{Sprites: sprites}))
+}
@@ -0,0 +1,83 @@
+// Command nekograph prints the state graph of a neko behavior as Graphviz DOT or Mermaid diagram.
+//
+// Usage:
+//
+//	nekograph [-format dot|mermaid|markdown] [-behavior file.json] [-options file.json] [-o output]
+//
+// Without -options, edges are labelled with the names of options they depend on.  With -options, the graph is evaluated for the given options, which override DefaultOptions.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	neko "github.com/tie/dummyneko"
+)
+
+func main() {
+	format := flag.String("format", "dot", "output `format`: dot, mermaid or markdown")
+	behavior := flag.String("behavior", "", "behavior definition `file`, defaults to the built-in behavior")
+	options := flag.String("options", "", "JSON `file` with options")
+	output := flag.String("o", "", "output `file`, defaults to standard output")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("nekograph: ")
+
+	bh := &neko.DefaultBehavior
+	source := "the built-in neko behavior"
+	if *behavior != "" {
+		source = fmt.Sprintf("the neko behavior in `%s`", *behavior)
+		f, err := os.Open(*behavior)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bh, err = neko.LoadBehavior(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *behavior, err)
+		}
+	}
+
+	var b *neko.Options
+	if *options != "" {
+		data, err := ioutil.ReadFile(*options)
+		if err != nil {
+			log.Fatal(err)
+		}
+		o := neko.DefaultOptions
+		if err := json.Unmarshal(data, &o); err != nil {
+			log.Fatalf("%s: %v", *options, err)
+		}
+		b = &o
+	}
+
+	g, err := bh.Graph(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out string
+	switch *format {
+	case "dot":
+		out = g.DOT()
+	case "mermaid":
+		out = g.Mermaid()
+	case "markdown":
+		out = fmt.Sprintf("# Behavior\n\nState graph of %s.  Generated by `go generate`, do not edit.\n\n```mermaid\n%s```\n", source, g.Mermaid())
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	if *output == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := ioutil.WriteFile(*output, []byte(out), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
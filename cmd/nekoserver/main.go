@@ -0,0 +1,50 @@
+// Command nekoserver runs a shared neko for many browsers.
+//
+// Browsers connect to the WebSocket endpoint at /ws, e.g. with the wasm host in client mode, which is enabled by setting the nekoServer global variable to the endpoint URL before loading the script.
+//
+// Usage:
+//
+//	nekoserver [-addr :8080] [-strategy nearest|recent|sticky|speed] [-static dir]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/server"
+)
+
+var strategies = map[string]neko.Strategy{
+	"nearest": neko.StrategyNearest,
+	"recent":  neko.StrategyRecent,
+	"sticky":  neko.StrategySticky,
+	"speed":   neko.StrategySpeed,
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen `address`")
+	strategy := flag.String("strategy", "nearest", "target selection `strategy`: nearest, recent, sticky or speed")
+	static := flag.String("static", "", "serve files from the `directory`")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("nekoserver: ")
+
+	st, ok := strategies[*strategy]
+	if !ok {
+		log.Fatalf("unknown strategy %q", *strategy)
+	}
+
+	srv := server.New(neko.DefaultOptions, st)
+	go srv.Run(context.Background())
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", srv)
+	if *static != "" {
+		mux.Handle("/", http.FileServer(http.Dir(*static)))
+	}
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -0,0 +1,160 @@
+package dummyneko
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Default number of directions in run and scratch sprite sets.
+const (
+	DefaultRunDirections     = 8
+	DefaultScratchDirections = 4
+)
+
+// compassPoints are the names of 16 compass points starting from east, clockwise.
+var compassPoints = [16]string{
+	"E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW",
+	"W", "WNW", "NW", "NNW",
+	"N", "NNE", "NE", "ENE",
+}
+
+// Sector quantizes the direction at the (x,y) to the destination (mx,my) coordinates into n equal sectors and returns the sector index.
+//
+// Sector 0 is centered on the east, and indices increase clockwise, e.g. for n = 4 sectors are E, S, W, N.  Directions on the boundary between two sectors belong to the one with the lower index, except for the boundary of the last sector, which belongs to sector 0.
+//
+// Note that it assumes a coordinate system with inverted Y axis, i.e. with origin at the top-left corner.  Use Coordinates.Sector method for other coordinate systems.
+func Sector(n uint, x, y, mx, my float64) uint {
+	if n == 0 {
+		return 0
+	}
+	w := 2 * π / float64(n)
+	// β is the angle from the destination to (x,y), turned around so that it runs from 0 at east through 2π.
+	β := math.Atan2(y-my, x-mx) + π
+	i := math.Ceil((β - w/2) / w)
+	return uint(i) % n
+}
+
+// Compass returns the name of the sector i out of n (see Sector function).
+//
+// If n divides 16, the name is a compass point, e.g. "N", "NE" or "NNE".  Otherwise, it is "D" followed by the bearing of the sector center in whole degrees clockwise from the north, e.g. "D120".
+func Compass(n, i uint) string {
+	if n == 0 {
+		return ""
+	}
+	i %= n
+	if 16%n == 0 {
+		return compassPoints[i*16/n]
+	}
+	bearing := math.Round(90 + float64(i)*360/float64(n))
+	return "D" + strconv.Itoa(int(bearing)%360)
+}
+
+// Coordinates is a coordinate system of neko and pointer positions.
+//
+// It affects facing directions, see Sector, and the sprite area around neko, see SpriteRect.  Distances, paths and Rect obstacles are the same in both systems, since a Rect is defined by its minimal and maximal corners.
+type Coordinates uint
+
+const (
+	// ScreenCoordinates have Y axis pointing down, i.e. origin is at the top-left corner.
+	ScreenCoordinates Coordinates = iota
+	// CartesianCoordinates have Y axis pointing up, i.e. origin is at the bottom-left corner.
+	CartesianCoordinates
+)
+
+// Sector is like Sector function, but uses the coordinate system c.  Sectors are still numbered clockwise as seen by the viewer, starting from the east.
+func (c Coordinates) Sector(n uint, x, y, mx, my float64) uint {
+	if c == CartesianCoordinates {
+		y, my = -y, -my
+	}
+	return Sector(n, x, y, mx, my)
+}
+
+// SpriteRect returns the area of a sprite of the given size at the neko position p.  The position is the top-left corner of the sprite as seen by the viewer, so the sprite extends toward positive Y on screen and toward negative Y in cartesian coordinates.
+func (c Coordinates) SpriteRect(p Pos, size float64) Rect {
+	if c == CartesianCoordinates {
+		return Rect{Min: Pos{p.X, p.Y - size}, Max: Pos{p.X + size, p.Y}}
+	}
+	return Rect{Min: p, Max: Pos{p.X + size, p.Y + size}}
+}
+
+func runDirections(b Options) uint {
+	if b.RunDirections == 0 {
+		return DefaultRunDirections
+	}
+	return b.RunDirections
+}
+
+func scratchDirections(b Options) uint {
+	if b.ScratchDirections == 0 {
+		return DefaultScratchDirections
+	}
+	return b.ScratchDirections
+}
+
+// facing returns the compass direction at the (x,y) to the destination (mx,my) coordinates quantized into n sectors.
+func facing(c Coordinates, n uint, x, y, mx, my float64) dir {
+	return dir(Compass(n, c.Sector(n, x, y, mx, my)))
+}
+
+// ActionsFor returns all actions that states may render or animate with the given Options.  It equals SupportedActions for the default sprite set.
+func ActionsFor(b Options) []Action {
+	as := []Action{
+		ActionAlert,
+		ActionStill,
+		ActionYawn,
+		ActionItch1,
+		ActionItch2,
+		ActionSleep1,
+		ActionSleep2,
+		ActionPurr1,
+		ActionPurr2,
+	}
+	for _, d := range directions(runDirections(b)) {
+		as = append(as, NewAction(KindRun, string(d), 1), NewAction(KindRun, string(d), 2))
+	}
+	for _, d := range directions(scratchDirections(b)) {
+		as = append(as, NewAction(KindScratch, string(d), 1), NewAction(KindScratch, string(d), 2))
+	}
+
+	seen := make(map[Action]bool, len(as))
+	for _, a := range as {
+		seen[a] = true
+	}
+	var ks []string
+	for k := range b.Animations {
+		ks = append(ks, string(k))
+	}
+	sort.Strings(ks)
+	for _, k := range ks {
+		k, anim := Kind(k), b.Animations[Kind(k)]
+		var prefixes []dir
+		switch k {
+		case KindRun:
+			prefixes = directions(runDirections(b))
+		case KindScratch:
+			prefixes = directions(scratchDirections(b))
+		default:
+			prefixes = []dir{""}
+		}
+		for _, d := range prefixes {
+			for _, f := range anim.direct(string(d)) {
+				if !seen[f.Action] {
+					seen[f.Action] = true
+					as = append(as, f.Action)
+				}
+			}
+		}
+	}
+	return as
+}
+
+// directions returns names of n compass directions in the order of SupportedActions, i.e. clockwise starting from the north.
+func directions(n uint) []dir {
+	ds := make([]dir, n)
+	for i := range ds {
+		ds[i] = dir(Compass(n, (uint(i)+n*3/4)%n))
+	}
+	return ds
+}
@@ -0,0 +1,141 @@
+package dummyneko
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSector(t *testing.T) {
+	cases := []struct {
+		n     uint
+		α     float64 // angle of the destination, clockwise from the east
+		e     uint
+		label string
+	}{
+		{4, 0, 0, "E"},
+		{4, π / 2, 1, "S"},
+		{4, π, 2, "W"},
+		{4, -π / 2, 3, "N"},
+		{8, π / 4, 1, "SE"},
+		{16, 0, 0, "E"},
+		{16, π / 8, 1, "ESE"},
+		{16, π/8 + π/12, 2, "SE"},
+		{16, -π / 8, 15, "ENE"},
+		{16, -π/2 + π/10, 13, "NNE"},
+		{16, -π/2 - π/17, 12, "N"},
+		{6, π / 3, 1, "D150"},
+		{6, -π / 3, 5, "D30"},
+	}
+	for _, c := range cases {
+		mx, my := 10*math.Cos(c.α), 10*math.Sin(c.α)
+		i := Sector(c.n, 0, 0, mx, my)
+		if i != c.e {
+			t.Errorf("Sector(%d, 0, 0, %f, %f) expected %d, got %d", c.n, mx, my, c.e, i)
+		}
+		if label := Compass(c.n, i); label != c.label {
+			t.Errorf("Compass(%d, %d) expected %q, got %q", c.n, i, c.label, label)
+		}
+	}
+}
+
+func TestActionsFor(t *testing.T) {
+	as := ActionsFor(DefaultOptions)
+	if len(as) != len(SupportedActions) {
+		t.Fatalf("expected %v, got %v", SupportedActions, as)
+	}
+	for i := range as {
+		if as[i] != SupportedActions[i] {
+			t.Fatalf("expected %v, got %v", SupportedActions, as)
+		}
+	}
+
+	as = ActionsFor(Options{RunDirections: 16, ScratchDirections: 8})
+	if e := 9 + 16*2 + 8*2; len(as) != e {
+		t.Errorf("expected %d actions, got %d", e, len(as))
+	}
+}
+
+func TestRunDirections(t *testing.T) {
+	b := Options{
+		Step:          1,
+		RunDirections: 16,
+	}
+	n := State{}
+	m := Pos{X: 10, Y: -4}
+	s := defaultState("run")
+	s = s.Next(n, m, b)
+	n = s.Render(n, m, b)
+	if n.Action != "enerun2" {
+		t.Errorf("expected %q, got %q", "enerun2", n.Action)
+	}
+}
+
+func TestCartesianCoordinates(t *testing.T) {
+	cases := []struct {
+		s string
+		m Pos
+		b Options
+		e Action
+	}{
+		{"run", Pos{0, 10}, Options{Step: 1}, ActionSRun1},
+		{"run", Pos{0, 10}, Options{Step: 1, Coordinates: CartesianCoordinates}, ActionNRun1},
+		{"run", Pos{10, -10}, Options{Step: 1, Coordinates: CartesianCoordinates}, ActionSERun1},
+		{"scratch", Pos{0, 10}, Options{}, ActionSScratch1},
+		{"scratch", Pos{0, 10}, Options{Coordinates: CartesianCoordinates}, ActionNScratch1},
+	}
+	for _, c := range cases {
+		n := defaultState(c.s).Render(State{}, c.m, c.b)
+		if n.Action != c.e {
+			t.Errorf("%s with pointer at %v and coordinates %d expected %q, got %q", c.s, c.m, c.b.Coordinates, c.e, n.Action)
+		}
+	}
+}
+
+func TestSpriteRect(t *testing.T) {
+	p := Pos{10, 20}
+	if r, e := ScreenCoordinates.SpriteRect(p, 32), (Rect{Min: Pos{10, 20}, Max: Pos{42, 52}}); r != e {
+		t.Errorf("screen: expected %v, got %v", e, r)
+	}
+	if r, e := CartesianCoordinates.SpriteRect(p, 32), (Rect{Min: Pos{10, -12}, Max: Pos{42, 20}}); r != e {
+		t.Errorf("cartesian: expected %v, got %v", e, r)
+	}
+}
+
+// TestCartesianMirror runs the default machine in both coordinate systems on a chase mirrored across the X axis.  Neko renders the same actions at mirrored positions.
+func TestCartesianMirror(t *testing.T) {
+	mirror := func(p Pos) Pos { return Pos{p.X, -p.Y} }
+
+	screen := DefaultOptions
+	screen.Obstacles = []Rect{{Min: Pos{100, 40}, Max: Pos{140, 200}}}
+	cartesian := DefaultOptions
+	cartesian.Coordinates = CartesianCoordinates
+	r := screen.Obstacles[0]
+	cartesian.Obstacles = []Rect{{Min: Pos{r.Min.X, -r.Max.Y}, Max: Pos{r.Max.X, -r.Min.Y}}}
+
+	pointer := func(i int) Pos {
+		switch {
+		case i < 10:
+			return Pos{}
+		case i < 40:
+			return Pos{200, 120}
+		case i < 70:
+			return Pos{float64(200 - 5*(i-40)), float64(120 + 3*(i-40))}
+		}
+		return Pos{50, 210}
+	}
+
+	var ns, nc State
+	ss, sc := NewInitialState(), NewInitialState()
+	for i := 0; i < 150; i++ {
+		m := pointer(i)
+		ss = ss.Next(ns, m, screen)
+		ns = ss.Render(ns, m, screen)
+		sc = sc.Next(nc, mirror(m), cartesian)
+		nc = sc.Render(nc, mirror(m), cartesian)
+
+		if StateName(ss) != StateName(sc) || ns.Action != nc.Action ||
+			math.Abs(ns.X-nc.X) > 1e-9 || math.Abs(ns.Y+nc.Y) > 1e-9 {
+			t.Fatalf("tick %d: screen %s %v, cartesian %s %v", i, StateName(ss), ns, StateName(sc), nc)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package dummyneko
+
+import (
+	"math"
+)
+
+// fatigueCond is an edge condition on the fatigue of neko rather than on Options.
+type fatigueCond string
+
+const (
+	fatigueNone   fatigueCond = ""
+	fatigueTired  fatigueCond = "tired"
+	fatigueRested fatigueCond = "rested"
+)
+
+func (c fatigueCond) eval(b Options, mem memory) bool {
+	switch c {
+	case fatigueTired:
+		return mem.exhausted
+	case fatigueRested:
+		return mem.fatigue < b.RestedFatigue
+	}
+	return true
+}
+
+// bounds reports whether the condition may hold with the given Options, and whether it always holds.  Fatigue starts at zero.
+func (c fatigueCond) bounds(b Options) (can, must bool) {
+	switch c {
+	case fatigueTired:
+		return b.TiredFatigue > 0 && b.FatigueRate > 0, false
+	case fatigueRested:
+		return b.RestedFatigue > 0, b.RestedFatigue > 0 && b.FatigueRate == 0
+	}
+	return true, true
+}
+
+// tire returns the fatigue after a tick of running or resting.
+func tire(fatigue float64, move, rest bool, b Options) float64 {
+	if move {
+		fatigue += b.FatigueRate
+	}
+	if rest {
+		fatigue -= b.RecoveryRate
+	}
+	return math.Max(0, math.Min(1, fatigue))
+}
+
+// exhaust returns whether neko is exhausted at the fatigue.  Neko gets exhausted at TiredFatigue and stays so until the fatigue falls below RestedFatigue, so that it rests properly instead of dozing off for a tick.
+func exhaust(exhausted bool, fatigue float64, b Options) bool {
+	switch {
+	case b.TiredFatigue <= 0:
+		return false
+	case fatigue >= b.TiredFatigue:
+		return true
+	case fatigue <= 0 || fatigue < b.RestedFatigue:
+		return false
+	}
+	return exhausted
+}
+
+// tired returns b with top speeds scaled down by fatigue.
+func tired(b Options, fatigue float64) Options {
+	if fatigue == 0 {
+		return b
+	}
+	k := 1 - fatigue/2
+	b.Step *= k
+	b.MaxSpeed *= k
+	b.SprintSpeed *= k
+	return b
+}
+
+// Fatigue returns the fatigue of the behavior state s, from 0 (rested) to 1 (exhausted).
+func Fatigue(s Transition) float64 {
+	if t, ok := s.(wrapper); ok {
+		return Fatigue(t.unwrap())
+	}
+	if s, ok := s.(node); ok {
+		return s.mem.fatigue
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestFatigueDisabled(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	s := NewInitialState()
+	n, m := State{}, Pos{}
+	for i := 0; i < 100; i++ {
+		m.X += 20
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if f := Fatigue(s); f != 0 {
+			t.Fatalf("tick %d: expected no fatigue with zero rates, got %v", i, f)
+		}
+	}
+	if StateName(s) != "run" {
+		t.Errorf("expected neko to chase forever, got %q", StateName(s))
+	}
+}
+
+func TestFatigueGiveUp(t *testing.T) {
+	b := Options{
+		Step:          10,
+		Dmax:          5,
+		FatigueRate:   0.25,
+		RecoveryRate:  0.1,
+		TiredFatigue:  0.5,
+		RestedFatigue: 0.2,
+	}
+	s := NewInitialState()
+	n, m := State{}, Pos{X: 1000}
+
+	var names []string
+	var xs, fs []float64
+	for i := 0; i < 16; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		names = append(names, StateName(s))
+		xs = append(xs, n.X)
+		fs = append(fs, Fatigue(s))
+	}
+
+	// Two ticks of running tire neko out, and it sleeps until it is rested.
+	e := []string{
+		"still", "alert", "run", "run", "yawn", "postyawn",
+		"sleep", "sleep", "sleep", "sleep", "alert", "run",
+		"run", "yawn", "postyawn", "sleep",
+	}
+	for i := range e {
+		if names[i] != e[i] {
+			t.Fatalf("expected states %v, got %v", e, names)
+		}
+	}
+	// Tired neko runs slower.
+	if xs[2] != 10 || xs[3] != 10+10*(1-0.25/2) {
+		t.Errorf("expected steps of 10 and 8.75, got positions %v", xs[2:4])
+	}
+	if fs[3] != 0.25 || fs[4] != 0.5 || fs[7] != 0.4 {
+		t.Errorf("expected fatigue to grow while running and recover in sleep, got %v", fs)
+	}
+	if fs[9] < b.RestedFatigue || fs[10] >= b.RestedFatigue {
+		t.Errorf("expected neko to wake once rested, got fatigue %v", fs)
+	}
+}
+
+func TestFatigueRested(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5, RestedFatigue: 0.2}
+	s := NewInitialState()
+	n, m := State{}, Pos{}
+	s = s.Next(n, m, b)
+	s = s.Next(n, m, b)
+	if StateName(s) != "itch" {
+		t.Errorf("expected rested neko to itch rather than yawn, got %q", StateName(s))
+	}
+
+	s, err := Force(s, "still", n, m, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.RestedFatigue = 0
+	if s = s.Next(n, m, b); StateName(s) != "yawn" {
+		t.Errorf("expected neko to yawn, got %q", StateName(s))
+	}
+}
+
+func TestFatigueCond(t *testing.T) {
+	cases := []struct {
+		s         string
+		b         Options
+		can, must bool
+	}{
+		{"tired", Options{}, false, false},
+		{"tired", Options{FatigueRate: 0.1, TiredFatigue: 0.5}, true, false},
+		{"!tired", Options{}, true, true},
+		{"!tired", Options{FatigueRate: 0.1, TiredFatigue: 0.5}, true, false},
+		{"rested", Options{}, false, false},
+		{"rested", Options{RestedFatigue: 0.2}, true, true},
+		{"rested", Options{RestedFatigue: 0.2, FatigueRate: 0.1}, true, false},
+		{"!rested", Options{RestedFatigue: 0.2}, false, false},
+	}
+	for _, c := range cases {
+		cd, err := compileCond(c.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if can, must := cd.bounds(c.b); can != c.can || must != c.must {
+			t.Errorf("%q with %+v: expected %v, %v; got %v, %v", c.s, c.b, c.can, c.must, can, must)
+		}
+	}
+
+	if _, err := compileCond("tired=1"); err == nil {
+		t.Error("expected error for a non-boolean fatigue condition")
+	}
+}
+
+func TestExhaust(t *testing.T) {
+	b := Options{TiredFatigue: 0.8, RestedFatigue: 0.2}
+	cases := []struct {
+		exhausted bool
+		fatigue   float64
+		b         Options
+		e         bool
+	}{
+		{false, 0.5, b, false},
+		{false, 0.8, b, true},
+		{true, 0.5, b, true},
+		{true, 0.2, b, true},
+		{true, 0.1, b, false},
+		{true, 0.1, Options{TiredFatigue: 0.8}, true},
+		{true, 0, Options{TiredFatigue: 0.8}, false},
+		{true, 0.9, Options{}, false},
+	}
+	for _, c := range cases {
+		if v := exhaust(c.exhausted, c.fatigue, c.b); v != c.e {
+			t.Errorf("exhaust(%v, %v) with %+v: expected %v, got %v", c.exhausted, c.fatigue, c.b, c.e, v)
+		}
+	}
+}
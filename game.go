@@ -0,0 +1,165 @@
+package dummyneko
+
+// Difficulty ramps Options up as a game goes on.
+type Difficulty struct {
+	// LevelTicks is the number of ticks per level.  Zero disables the ramp.
+	LevelTicks uint
+	// MaxLevel caps the level.
+	MaxLevel uint
+	// SpeedUp is the fraction of Step, MaxSpeed and SprintSpeed added on each level.
+	SpeedUp float64
+	// AlertTicks are shortened by one on each level down to MinAlertTicks.
+	MinAlertTicks uint
+}
+
+// DefaultDifficulty speeds neko up by a tenth every 15 seconds of the default tick duration.
+var DefaultDifficulty = Difficulty{
+	LevelTicks:    50,
+	MaxLevel:      10,
+	SpeedUp:       0.1,
+	MinAlertTicks: 1,
+}
+
+// Score is the state of a game, in ticks.
+type Score struct {
+	Running bool `json:"running"`
+	// Survival is the number of ticks since the start or the last catch that the pointer spent out of reach of neko.
+	Survival uint `json:"survival"`
+	// Best is the longest survival.
+	Best    uint `json:"best"`
+	Catches uint `json:"catches"`
+	Level   uint `json:"level"`
+}
+
+// Game keeps score of how long the pointer escapes neko.  It wraps the state machine like StatsCollector, and ramps the difficulty of Options passed to the wrapped Transitions while running.
+type Game struct {
+	Difficulty Difficulty
+
+	running  bool
+	ticks    uint
+	survival uint
+	best     uint
+	catches  uint
+}
+
+// NewGame returns a stopped Game with the high score best, e.g. restored from local storage.
+func NewGame(d Difficulty, best uint) *Game {
+	return &Game{Difficulty: d, best: best}
+}
+
+// Wrap returns a Transition that behaves like s and reports to the game.
+func (g *Game) Wrap(s Transition) Transition {
+	return gameTransition{s, g}
+}
+
+// Start starts a new game.  It is a no-op if the game is already running.
+func (g *Game) Start() {
+	if g.running {
+		return
+	}
+	g.running = true
+	g.ticks, g.survival, g.catches = 0, 0, 0
+}
+
+// Stop stops the game and keeps the score.
+func (g *Game) Stop() {
+	g.running = false
+}
+
+// Level returns the current difficulty level.
+func (g *Game) Level() uint {
+	d := g.Difficulty
+	if !g.running || d.LevelTicks == 0 {
+		return 0
+	}
+	level := g.ticks / d.LevelTicks
+	if level > d.MaxLevel {
+		level = d.MaxLevel
+	}
+	return level
+}
+
+// Options returns b ramped up to the current level.
+func (g *Game) Options(b Options) Options {
+	return g.Difficulty.options(b, g.Level())
+}
+
+// options returns b ramped up to the level.
+func (d Difficulty) options(b Options, level uint) Options {
+	if level == 0 {
+		return b
+	}
+	k := 1 + float64(level)*d.SpeedUp
+	b.Step *= k
+	b.MaxSpeed *= k
+	b.SprintSpeed *= k
+
+	min := d.MinAlertTicks
+	switch {
+	case b.AlertTicks < min:
+	case b.AlertTicks-min < level:
+		b.AlertTicks = min
+	default:
+		b.AlertTicks -= level
+	}
+	return b
+}
+
+// Score returns the current score.
+func (g *Game) Score() Score {
+	return Score{
+		Running:  g.running,
+		Survival: g.survival,
+		Best:     g.best,
+		Catches:  g.catches,
+		Level:    g.Level(),
+	}
+}
+
+type gameTransition struct {
+	s Transition
+	g *Game
+}
+
+func (t gameTransition) Next(n State, m Pos, b Options) Transition {
+	g := t.g
+	if g.running {
+		// The tick is counted first, so that Render and Animate of the tick see the same level.
+		g.ticks += 1
+	}
+	b = g.Options(b)
+	next := t.s.Next(n, m, b)
+	if !g.running {
+		return gameTransition{next, g}
+	}
+
+	switch {
+	case caught(t.s, next, n, m, b):
+		g.catches += 1
+		g.survival = 0
+	case !pointerNearby(n, m, b):
+		// A caught pointer that stays under neko does not survive.
+		g.survival += 1
+	}
+	if g.survival > g.best {
+		g.best = g.survival
+	}
+	return gameTransition{next, g}
+}
+
+func (t gameTransition) Render(n State, m Pos, b Options) State {
+	return t.s.Render(n, m, t.g.Options(b))
+}
+
+func (t gameTransition) unwrap() Transition {
+	return t.s
+}
+
+func (t gameTransition) rewrap(s Transition) Transition {
+	return gameTransition{s, t.g}
+}
+
+// Animate implements Animator.
+func (t gameTransition) Animate(n State, m Pos, b Options) Animation {
+	return Animate(t.s, n, m, t.g.Options(b))
+}
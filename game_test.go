@@ -0,0 +1,158 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestGameOptions(t *testing.T) {
+	g := NewGame(Difficulty{LevelTicks: 10, MaxLevel: 2, SpeedUp: 0.5, MinAlertTicks: 1}, 0)
+	b := Options{Step: 10, MaxSpeed: 20, AlertTicks: 4}
+
+	cases := []struct {
+		ticks      uint
+		level      uint
+		step       float64
+		maxSpeed   float64
+		alertTicks uint
+	}{
+		{0, 0, 10, 20, 4},
+		{9, 0, 10, 20, 4},
+		{10, 1, 15, 30, 3},
+		{25, 2, 20, 40, 2},
+		{100, 2, 20, 40, 2},
+	}
+	g.Start()
+	for _, c := range cases {
+		g.ticks = c.ticks
+		o := g.Options(b)
+		if g.Level() != c.level || o.Step != c.step || o.MaxSpeed != c.maxSpeed || o.AlertTicks != c.alertTicks {
+			t.Errorf("after %d ticks: expected level %d, step %v, max speed %v, alert ticks %d; got %d, %v, %v, %d",
+				c.ticks, c.level, c.step, c.maxSpeed, c.alertTicks, g.Level(), o.Step, o.MaxSpeed, o.AlertTicks)
+		}
+	}
+
+	g.Difficulty.MinAlertTicks = 3
+	if o := g.Options(b); o.AlertTicks != 3 {
+		t.Errorf("expected alert ticks clamped to 3, got %d", o.AlertTicks)
+	}
+
+	g.Stop()
+	if o := g.Options(b); o.Step != b.Step {
+		t.Errorf("expected no ramp when stopped, got step %v", o.Step)
+	}
+}
+
+func TestGameScore(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	g := NewGame(Difficulty{}, 3)
+	s := g.Wrap(NewInitialState())
+	n, m := State{}, Pos{}
+
+	tick := func(k int) {
+		for i := 0; i < k; i++ {
+			s = s.Next(n, m, b)
+			n = s.Render(n, m, b)
+		}
+	}
+
+	tick(5)
+	if sc := g.Score(); sc.Running || sc.Survival != 0 || sc.Best != 3 {
+		t.Errorf("expected stopped game to keep no score, got %+v", sc)
+	}
+
+	g.Start()
+	tick(2)
+	m = Pos{X: 50}
+	tick(10)
+	sc := g.Score()
+	if sc.Catches != 1 {
+		t.Fatalf("expected a catch, got %+v", sc)
+	}
+	if sc.Best < 5 || sc.Survival >= sc.Best {
+		t.Errorf("expected survival reset after the catch, got %+v", sc)
+	}
+
+	// Starting a running game is a no-op.
+	g.Start()
+	if g.Score() != sc {
+		t.Errorf("expected score to be kept, got %+v", g.Score())
+	}
+
+	// The caught pointer idles under neko.
+	tick(20)
+	if sc2 := g.Score(); sc2.Survival != sc.Survival || sc2.Best != sc.Best {
+		t.Errorf("expected no survival while caught, got %+v", sc2)
+	}
+
+	m = Pos{X: 500}
+	tick(20)
+	best := sc.Best
+	if best < 20 {
+		best = 20
+	}
+	if sc2 := g.Score(); sc2.Survival != 20 || sc2.Best != best {
+		t.Errorf("expected survival to grow out of reach, got %+v", sc2)
+	}
+
+	g.Stop()
+	g.Start()
+	if sc := g.Score(); !sc.Running || sc.Catches != 0 || sc.Survival != 0 || sc.Best < 20 {
+		t.Errorf("expected a new game with the high score kept, got %+v", sc)
+	}
+}
+
+func TestGameWrapped(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	stats := NewStatsCollector()
+	g := NewGame(DefaultDifficulty, 0)
+	s := stats.Wrap(g.Wrap(NewInitialState()))
+
+	s, err := Force(s, "run", State{}, Pos{X: 100}, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if StateName(s) != "run" || !Moving(s) {
+		t.Errorf("expected run state through wrappers, got %q", StateName(s))
+	}
+	g.Start()
+	s = s.Next(State{}, Pos{X: 100}, b)
+	s.Render(State{}, Pos{X: 100}, b)
+	if g.Score().Survival != 1 || stats.Stats().Ticks["run"] != 1 {
+		t.Errorf("expected both wrappers to stay in place, got %+v and %+v", g.Score(), stats.Stats())
+	}
+}
+
+// stepRecorder records steps of options it is called with.
+type stepRecorder struct {
+	next, render []float64
+}
+
+func (r *stepRecorder) Next(n State, m Pos, b Options) Transition {
+	r.next = append(r.next, b.Step)
+	return r
+}
+
+func (r *stepRecorder) Render(n State, m Pos, b Options) State {
+	r.render = append(r.render, b.Step)
+	return n
+}
+
+func TestGameLevelConsistency(t *testing.T) {
+	r := &stepRecorder{}
+	g := NewGame(Difficulty{LevelTicks: 2, MaxLevel: 3, SpeedUp: 1}, 0)
+	g.Start()
+	s := g.Wrap(r)
+	b := Options{Step: 1}
+	for i := 0; i < 6; i++ {
+		s = s.Next(State{}, Pos{}, b)
+		s.Render(State{}, Pos{}, b)
+	}
+	for i := range r.next {
+		if r.next[i] != r.render[i] {
+			t.Fatalf("expected the same steps in Next and Render, got %v and %v", r.next, r.render)
+		}
+	}
+	if r.next[0] == r.next[5] {
+		t.Errorf("expected the level to ramp up, got steps %v", r.next)
+	}
+}
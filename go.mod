@@ -2,4 +2,7 @@ module github.com/tie/dummyneko
 
 go 1.14
 
-require github.com/gopherjs/gopherwasm v1.1.0
+require (
+	github.com/gopherjs/gopherwasm v1.1.0
+	golang.org/x/net v0.0.0-20200822124328-c89045814202
+)
@@ -0,0 +1,187 @@
+package dummyneko
+
+//go:generate go run ./cmd/nekograph -format markdown -o docs/behavior.md
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Graph is a state graph of a Behavior.
+type Graph struct {
+	Initial string
+	States  []string
+	Edges   []GraphEdge
+}
+
+// GraphEdge is a labelled edge of Graph.
+type GraphEdge struct {
+	From, To string
+	Label    string
+}
+
+// Graph returns the graph of states reachable from the initial state.
+//
+// If b is not nil, edge conditions and tick thresholds are evaluated with the given Options, and edges that can never be taken are omitted.  Otherwise, edges are labelled with the names of options they depend on.
+func (bh *Behavior) Graph(b *Options) (*Graph, error) {
+	m, err := compileBehavior(bh)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{Initial: bh.Initial}
+	seen := make([]bool, len(m.states))
+	queue := []int{m.initial}
+	seen[m.initial] = true
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		s := &m.states[i]
+		g.States = append(g.States, s.Name)
+
+		shadowed := make(map[Guard]bool)
+		for j, e := range s.edges {
+			if shadowed[e.on] {
+				continue
+			}
+			if e.on == GuardDone && s.count == nil {
+				continue
+			}
+			if b != nil && !e.on.possible(*b) {
+				continue
+			}
+			// Conditions that depend on the state of neko rather than on options are kept in labels.
+			dynamic := !e.cond.static()
+			if b != nil {
+				can, must := e.cond.bounds(*b)
+				if !can {
+					continue
+				}
+				dynamic = !must
+			}
+			if !dynamic && (b != nil || e.cond.field == nil) {
+				shadowed[e.on] = true
+			}
+
+			g.Edges = append(g.Edges, GraphEdge{
+				From:  s.Name,
+				To:    m.states[e.to].Name,
+				Label: edgeLabel(s, s.Edges[j], b, dynamic),
+			})
+			if !seen[e.to] {
+				seen[e.to] = true
+				queue = append(queue, e.to)
+			}
+		}
+	}
+	return g, nil
+}
+
+func edgeLabel(s *compiledState, e Edge, b *Options, dynamic bool) string {
+	var label string
+	switch e.On {
+	case GuardNear:
+		label = "pointer near"
+	case GuardFar:
+		label = "pointer far"
+	case GuardDone:
+		label = "after " + doneTicks(s, b) + " ticks"
+	case GuardPet:
+		label = "pointer pets"
+	case GuardStartle:
+		label = "pointer startles"
+	case GuardLeave:
+		label = "pointer leaves"
+	}
+	if (b == nil || dynamic) && e.If != "" {
+		label += " if " + e.If
+	}
+	return label
+}
+
+// doneTicks returns the number of ticks spent in the state before it is done.
+func doneTicks(s *compiledState, b *Options) string {
+	if b == nil {
+		switch {
+		case s.Count.Option == "" && s.Count.Value == 0:
+			return "1"
+		case s.Count.Option == "" && s.Count.Value == 1:
+			return s.Ticks.String()
+		}
+		return s.Count.String() + "×" + s.Ticks.String()
+	}
+
+	count := s.count.eval(*b)
+	if count == 0 {
+		return "1"
+	}
+	ticks := s.ticks.eval(*b)
+	if ticks == 0 {
+		ticks = 1
+	}
+	return strconv.FormatUint(uint64(ticks*count), 10)
+}
+
+// DOT returns the graph in Graphviz DOT language.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	start := g.startID()
+	sb.WriteString("digraph neko {\n")
+	fmt.Fprintf(&sb, "\t%q [shape=point];\n", start)
+	for _, s := range g.States {
+		fmt.Fprintf(&sb, "\t%q;\n", s)
+	}
+	fmt.Fprintf(&sb, "\t%q -> %q;\n", start, g.Initial)
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "\t%q -> %q [label=%q];\n", e.From, e.To, e.Label)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid returns the graph as Mermaid state diagram.
+func (g *Graph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("stateDiagram-v2\n")
+	ids := make(map[string]string, len(g.States))
+	for i, s := range g.States {
+		if mermaidName.MatchString(s) && !mermaidKeywords[s] {
+			ids[s] = s
+			continue
+		}
+		// Other names are declared with generated ids.
+		ids[s] = fmt.Sprintf("state%d", i)
+		fmt.Fprintf(&sb, "\tstate \"%s\" as %s\n", strings.Replace(s, `"`, "#quot;", -1), ids[s])
+	}
+	fmt.Fprintf(&sb, "\t[*] --> %s\n", ids[g.Initial])
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "\t%s --> %s : %s\n", ids[e.From], ids[e.To], e.Label)
+	}
+	return sb.String()
+}
+
+// mermaidName matches state names that are valid Mermaid ids as they are.
+var mermaidName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// mermaidKeywords are not usable as state ids.
+var mermaidKeywords = map[string]bool{"state": true, "note": true, "direction": true, "class": true, "classDef": true, "end": true}
+
+// startID returns the DOT id of the entry point, one that is not a state name.
+func (g *Graph) startID() string {
+	id := "__start"
+	for {
+		taken := false
+		for _, s := range g.States {
+			if s == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return id
+		}
+		id += "_"
+	}
+}
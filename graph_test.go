@@ -0,0 +1,99 @@
+package dummyneko
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	b := DefaultOptions
+	b.StillTransition = 2
+	b.ScratchDisableAlert = false
+
+	g, err := DefaultBehavior.Graph(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dot := g.DOT()
+	for _, e := range []string{
+		`"__start" -> "still";`,
+		`"still" -> "scratch" [label="after 4 ticks"];`,
+		`"scratch" -> "alert" [label="pointer far"];`,
+		`"scratch" -> "postscratch" [label="after 8 ticks"];`,
+		`"alert" -> "run" [label="after 2 ticks"];`,
+		`"run" -> "still" [label="pointer near"];`,
+	} {
+		if !strings.Contains(dot, e) {
+			t.Errorf("DOT output does not contain %s:\n%s", e, dot)
+		}
+	}
+	for _, s := range []string{"itch", "postitch"} {
+		if strings.Contains(dot, `"`+s+`"`) {
+			t.Errorf("DOT output contains unreachable state %q:\n%s", s, dot)
+		}
+	}
+
+	mermaid := g.Mermaid()
+	for _, e := range []string{
+		"[*] --> still\n",
+		"still --> scratch : after 4 ticks\n",
+		"postyawn --> sleep : after 4 ticks\n",
+	} {
+		if !strings.Contains(mermaid, e) {
+			t.Errorf("Mermaid output does not contain %q:\n%s", e, mermaid)
+		}
+	}
+}
+
+func TestGraphDocs(t *testing.T) {
+	data, err := ioutil.ReadFile("docs/behavior.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := DefaultBehavior.Graph(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), g.Mermaid()) {
+		t.Errorf("docs/behavior.md is out of date, run go generate")
+	}
+}
+
+func TestGraphIDs(t *testing.T) {
+	g := &Graph{
+		Initial: "start",
+		States:  []string{"start", "__start", "big nap", `say "hi"`, "state"},
+		Edges: []GraphEdge{
+			{From: "start", To: "big nap", Label: "after 1 tick"},
+			{From: "big nap", To: `say "hi"`, Label: "after 1 tick"},
+			{From: `say "hi"`, To: "state", Label: "after 1 tick"},
+		},
+	}
+
+	dot := g.DOT()
+	for _, e := range []string{
+		`"__start_" [shape=point];`,
+		`"__start_" -> "start";`,
+		`"big nap" -> "say \"hi\"" [label="after 1 tick"];`,
+	} {
+		if !strings.Contains(dot, e) {
+			t.Errorf("DOT output does not contain %s:\n%s", e, dot)
+		}
+	}
+
+	mermaid := g.Mermaid()
+	for _, e := range []string{
+		"[*] --> start\n",
+		"state \"big nap\" as state2\n",
+		"state \"say #quot;hi#quot;\" as state3\n",
+		"state \"state\" as state4\n",
+		"start --> state2 : after 1 tick\n",
+		"state3 --> state4 : after 1 tick\n",
+	} {
+		if !strings.Contains(mermaid, e) {
+			t.Errorf("Mermaid output does not contain %q:\n%s", e, mermaid)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package host
+
+import (
+	"time"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// Input is sampled by the loop on each tick.
+type Input interface {
+	// Pointer returns the pointer position.
+	Pointer() neko.Pos
+	// Obstacles returns rectangles that neko runs around.
+	Obstacles() []neko.Rect
+}
+
+// Loop advances the state machine on each tick and displays animation frames in between.
+//
+// Loop is not safe for concurrent use.  Its methods are called from scheduler callbacks or, in a browser, from event handlers that run on the same thread.
+type Loop struct {
+	// BeforeTick is called with the options of a tick before the state machine advances, e.g. to record them.
+	BeforeTick func(neko.Options)
+	// AfterTick is called with the Transition and the rendered state after each tick.
+	AfterTick func(neko.Transition, neko.State)
+
+	sch Scheduler
+	in  Input
+	r   Renderer
+	b   neko.Options
+	s   neko.Transition
+	n   neko.State
+
+	anim      neko.Animation
+	animStart time.Time
+	// time of the next tick
+	next time.Time
+	// cancel cancels the scheduled run, nil when the loop is stopped.
+	cancel func()
+}
+
+// NewLoop returns a stopped Loop driving s with the options b and drawing with r.
+func NewLoop(s neko.Transition, b neko.Options, sch Scheduler, in Input, r Renderer) *Loop {
+	return &Loop{sch: sch, in: in, r: r, b: b, s: s}
+}
+
+// Start ticks and schedules the following ticks and frames.  Starting a running loop does nothing.
+func (l *Loop) Start() {
+	if l.Running() {
+		return
+	}
+	l.cancel = func() {}
+	l.next = l.sch.Now()
+	l.run()
+}
+
+// Stop cancels the following ticks and frames.  The state is kept, so Start resumes the loop.
+func (l *Loop) Stop() {
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+}
+
+// Running reports whether the loop is started.
+func (l *Loop) Running() bool {
+	return l.cancel != nil
+}
+
+// Options returns the options of the loop.
+func (l *Loop) Options() neko.Options {
+	return l.b
+}
+
+// SetOptions changes the options from the next tick on.
+func (l *Loop) SetOptions(b neko.Options) {
+	l.b = b
+}
+
+// State returns the state rendered on the last tick.
+func (l *Loop) State() neko.State {
+	return l.n
+}
+
+// SetState replaces the state, e.g. to teleport neko.  It is displayed from the next frame on.
+func (l *Loop) SetState(n neko.State) {
+	l.n = n
+}
+
+// SetRenderer replaces the renderer from the next frame on.
+func (l *Loop) SetRenderer(r Renderer) {
+	l.r = r
+}
+
+// Transition returns the current Transition.
+func (l *Loop) Transition() neko.Transition {
+	return l.s
+}
+
+// Reset replaces the Transition from the next tick on, e.g. to start over for a new recording.
+func (l *Loop) Reset(s neko.Transition) {
+	l.s = s
+}
+
+func (l *Loop) tickDuration() time.Duration {
+	if l.b.TickDuration <= 0 {
+		return neko.DefaultTickDuration
+	}
+	return l.b.TickDuration
+}
+
+// run is the scheduler callback.  It ticks when the tick is due, displays the current frame and schedules itself for the next frame or tick, whichever comes first.
+func (l *Loop) run() {
+	now := l.sch.Now()
+	if !now.Before(l.next) {
+		l.tick(now)
+		l.next = l.next.Add(l.tickDuration())
+		if !l.next.After(now) {
+			// Skip missed ticks, e.g. when the page was in background.
+			l.next = now.Add(l.tickDuration())
+		}
+	}
+
+	v := l.n
+	var left time.Duration
+	v.Action, left = l.anim.At(now.Sub(l.animStart))
+	l.r.Draw(v)
+	if !l.Running() {
+		// Stopped by a callback.
+		return
+	}
+
+	wait := l.next.Sub(now)
+	if left > 0 && left < wait {
+		wait = left
+	}
+	l.cancel = l.sch.After(wait, l.run)
+}
+
+// tick samples input and advances the state machine.
+func (l *Loop) tick(now time.Time) {
+	b := l.b
+	b.Obstacles = l.in.Obstacles()
+	m := l.in.Pointer()
+	if l.BeforeTick != nil {
+		l.BeforeTick(b)
+	}
+
+	l.s = l.s.Next(l.n, m, b)
+	l.n = l.s.Render(l.n, m, b)
+	if l.AfterTick != nil {
+		l.AfterTick(l.s, l.n)
+	}
+	if a := neko.Animate(l.s, l.n, m, b); !a.Equal(l.anim) {
+		l.anim, l.animStart = a, now
+	}
+
+	// Cycle idle activities like WebNeko does.
+	switch l.b.StillTransition {
+	case 0:
+		l.b.StillTransition = 2
+	case 1:
+		l.b.StillTransition = 0
+	case 2:
+		l.b.StillTransition = 1
+	}
+}
@@ -0,0 +1,176 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// fakeInput is an Input with fixed values.
+type fakeInput struct {
+	m         neko.Pos
+	obstacles []neko.Rect
+}
+
+func (in *fakeInput) Pointer() neko.Pos {
+	return in.m
+}
+
+func (in *fakeInput) Obstacles() []neko.Rect {
+	return in.obstacles
+}
+
+func TestLoop(t *testing.T) {
+	const tick = 100 * time.Millisecond
+	b := neko.Options{Step: 10, Dmax: 5, TickDuration: tick}
+	c := NewManual(time.Unix(0, 0))
+	in := &fakeInput{m: neko.Pos{X: 1000}}
+	r := &Memory{}
+	l := NewLoop(neko.NewInitialState(), b, c, in, r)
+
+	var events []string
+	l.BeforeTick = func(neko.Options) {
+		events = append(events, "before")
+	}
+	l.AfterTick = func(s neko.Transition, n neko.State) {
+		events = append(events, "after "+neko.StateName(s))
+	}
+
+	l.Start()
+	c.Advance(3 * tick)
+
+	e := []string{
+		"before", "after still",
+		"before", "after alert",
+		"before", "after run",
+		"before", "after run",
+	}
+	if len(events) != len(e) {
+		t.Fatalf("expected events %v, got %v", e, events)
+	}
+	for i := range e {
+		if events[i] != e[i] {
+			t.Fatalf("expected events %v, got %v", e, events)
+		}
+	}
+	if n := l.State(); n.X != 20 {
+		t.Errorf("expected neko at 20, got %v", n.X)
+	}
+	if last, ok := r.Last(); len(r.States) != 4 || !ok || last != l.State() {
+		t.Errorf("expected a frame per tick, got %v", r.States)
+	}
+	if neko.StateName(l.Transition()) != "run" {
+		t.Errorf("expected run state, got %q", neko.StateName(l.Transition()))
+	}
+}
+
+func TestLoopFrames(t *testing.T) {
+	const tick = 100 * time.Millisecond
+	b := neko.Options{
+		Step:         10,
+		Dmax:         5,
+		TickDuration: tick,
+		Animations: map[neko.Kind]neko.Animation{
+			neko.KindStill: {{Action: "a", Duration: 30 * time.Millisecond}, {Action: "b", Duration: 20 * time.Millisecond}},
+		},
+	}
+	c := NewManual(time.Unix(0, 0))
+	r := &Memory{}
+	l := NewLoop(neko.NewInitialState(), b, c, &fakeInput{}, r)
+	l.Start()
+	c.Advance(tick - time.Millisecond)
+	actions := r.Actions()
+
+	// Frames at 0, 30, 50, 80ms of the still animation within a single tick.
+	e := []neko.Action{"a", "b", "a", "b"}
+	if len(actions) != len(e) {
+		t.Fatalf("expected frames %v, got %v", e, actions)
+	}
+	for i := range e {
+		if actions[i] != e[i] {
+			t.Fatalf("expected frames %v, got %v", e, actions)
+		}
+	}
+}
+
+func TestLoopOptions(t *testing.T) {
+	b := neko.Options{Step: 10, Dmax: 5, StillTransition: 1}
+	c := NewManual(time.Unix(0, 0))
+	l := NewLoop(neko.NewInitialState(), b, c, &fakeInput{}, &Memory{})
+	l.Start()
+
+	// Idle activities cycle on each tick.
+	e := []uint{0, 2, 1, 0}
+	for i, v := range e {
+		if l.Options().StillTransition != v {
+			t.Fatalf("tick %d: expected StillTransition %d, got %d", i, v, l.Options().StillTransition)
+		}
+		c.Advance(neko.DefaultTickDuration)
+	}
+
+	b.Step = 20
+	l.SetOptions(b)
+	l.Reset(neko.NewInitialState())
+	if l.Options().Step != 20 || neko.StateName(l.Transition()) != "" {
+		t.Error("expected options and transition to be replaced")
+	}
+
+	n := neko.State{X: 100, Y: 50, Action: neko.ActionStill}
+	l.SetState(n)
+	if l.State() != n {
+		t.Errorf("expected state %v, got %v", n, l.State())
+	}
+
+	r := &Memory{}
+	l.SetRenderer(r)
+	c.Advance(neko.DefaultTickDuration)
+	if len(r.States) == 0 {
+		t.Error("expected frames drawn by the new renderer")
+	}
+}
+
+func TestLoopStop(t *testing.T) {
+	const tick = 100 * time.Millisecond
+	b := neko.Options{Step: 10, Dmax: 5, TickDuration: tick}
+	c := NewManual(time.Unix(0, 0))
+	r := &Memory{}
+	l := NewLoop(neko.NewInitialState(), b, c, &fakeInput{m: neko.Pos{X: 1000}}, r)
+
+	ticks := 0
+	l.AfterTick = func(neko.Transition, neko.State) {
+		ticks++
+	}
+
+	l.Start()
+	l.Start()
+	if c.Pending() != 1 {
+		t.Fatalf("expected starting twice to schedule a single run, got %d", c.Pending())
+	}
+	c.Advance(tick)
+
+	l.Stop()
+	l.Stop()
+	if l.Running() || c.Pending() != 0 {
+		t.Fatalf("expected stopped loop to cancel its run, got %d pending", c.Pending())
+	}
+	c.Advance(10 * tick)
+	if ticks != 2 {
+		t.Errorf("expected no ticks while stopped, got %d ticks", ticks)
+	}
+
+	l.Start()
+	c.Advance(tick)
+	if ticks != 4 || neko.StateName(l.Transition()) != "run" {
+		t.Errorf("expected restarted loop to resume, got %d ticks in %q", ticks, neko.StateName(l.Transition()))
+	}
+
+	// Stopping from a callback keeps the loop stopped.
+	l.AfterTick = func(neko.Transition, neko.State) {
+		l.Stop()
+	}
+	c.Advance(tick)
+	if l.Running() || c.Pending() != 0 {
+		t.Errorf("expected loop stopped by a callback, got %d pending", c.Pending())
+	}
+}
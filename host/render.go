@@ -0,0 +1,38 @@
+package host
+
+import (
+	neko "github.com/tie/dummyneko"
+)
+
+// Renderer displays neko.
+type Renderer interface {
+	// Draw shows the state.  Its action is the one of the current animation frame.
+	Draw(neko.State)
+}
+
+// Memory is a Renderer that keeps drawn states, e.g. for tests.
+type Memory struct {
+	States []neko.State
+}
+
+// Draw implements Renderer.
+func (r *Memory) Draw(n neko.State) {
+	r.States = append(r.States, n)
+}
+
+// Last returns the last drawn state.
+func (r *Memory) Last() (neko.State, bool) {
+	if len(r.States) == 0 {
+		return neko.State{}, false
+	}
+	return r.States[len(r.States)-1], true
+}
+
+// Actions returns actions of drawn states.
+func (r *Memory) Actions() []neko.Action {
+	as := make([]neko.Action, len(r.States))
+	for i, n := range r.States {
+		as[i] = n.Action
+	}
+	return as
+}
@@ -0,0 +1,113 @@
+// Package host runs the neko state machine for interactive hosts, e.g. the wasm host in a browser, independently of the platform.
+//
+// The loop samples input, advances the state machine on each tick and displays animation frames in between.  Time comes from a Scheduler, so the loop runs in real time, on browser animation frames, or under tests with a Manual clock.
+package host
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// Scheduler calls functions back at a later time.
+type Scheduler interface {
+	neko.Clock
+	// After calls f once at least d from now and returns a function that cancels the call.
+	After(d time.Duration, f func()) (cancel func())
+}
+
+// RealTime is a Scheduler on the system clock.  Callbacks run on their own goroutines.
+type RealTime struct{}
+
+// Now implements neko.Clock.
+func (RealTime) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Scheduler.
+func (RealTime) After(d time.Duration, f func()) func() {
+	t := time.AfterFunc(d, f)
+	return func() { t.Stop() }
+}
+
+// Manual is a Scheduler for tests.  Its time advances only on Advance calls, which run due callbacks.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    int
+	timers []*manualTimer
+}
+
+type manualTimer struct {
+	at  time.Time
+	seq int
+	f   func()
+}
+
+// NewManual returns a Manual scheduler starting at the time t.
+func NewManual(t time.Time) *Manual {
+	return &Manual{now: t}
+}
+
+// Now implements neko.Clock.
+func (c *Manual) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements Scheduler.
+func (c *Manual) After(d time.Duration, f func()) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	t := &manualTimer{at: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, u := range c.timers {
+			if u == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Pending returns the number of scheduled callbacks.
+func (c *Manual) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Advance moves the time forward by d and runs callbacks due by then in order.  Each callback sees its due time as Now, and callbacks scheduled by them run too if they are due.
+func (c *Manual) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.Slice(c.timers, func(i, j int) bool {
+			a, b := c.timers[i], c.timers[j]
+			if a.at.Equal(b.at) {
+				return a.seq < b.seq
+			}
+			return a.at.Before(b.at)
+		})
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			break
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
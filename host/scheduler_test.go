@@ -0,0 +1,65 @@
+package host
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewManual(start)
+
+	var calls []time.Duration
+	record := func() {
+		calls = append(calls, c.Now().Sub(start))
+	}
+	c.After(30*time.Millisecond, record)
+	c.After(10*time.Millisecond, func() {
+		record()
+		c.After(5*time.Millisecond, record)
+	})
+	c.After(10*time.Millisecond, record)
+	cancel := c.After(20*time.Millisecond, record)
+	cancel()
+
+	c.Advance(25 * time.Millisecond)
+	e := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 15 * time.Millisecond}
+	if len(calls) != len(e) {
+		t.Fatalf("expected calls at %v, got %v", e, calls)
+	}
+	for i := range e {
+		if calls[i] != e[i] {
+			t.Fatalf("expected calls at %v, got %v", e, calls)
+		}
+	}
+	if now := c.Now().Sub(start); now != 25*time.Millisecond {
+		t.Errorf("expected time 25ms, got %v", now)
+	}
+	if c.Pending() != 1 {
+		t.Errorf("expected a pending callback, got %d", c.Pending())
+	}
+
+	c.Advance(5 * time.Millisecond)
+	if len(calls) != 4 || calls[3] != 30*time.Millisecond {
+		t.Errorf("expected a call at 30ms, got %v", calls)
+	}
+}
+
+func TestRealTime(t *testing.T) {
+	done := make(chan struct{})
+	RealTime{}.After(time.Millisecond, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callback was not called")
+	}
+
+	called := make(chan struct{}, 1)
+	cancel := RealTime{}.After(10*time.Millisecond, func() { called <- struct{}{} })
+	cancel()
+	select {
+	case <-called:
+		t.Error("canceled callback was called")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
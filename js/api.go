@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gopherjs/gopherwasm/js"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// errClientMode is returned by API calls that need the local simulation.
+var errClientMode = errors.New("neko is simulated by the server")
+
+// Events that listeners subscribe to.
+const (
+	// eventTick is emitted with the state after each tick.
+	eventTick = "tick"
+	// eventTransition is emitted with {from, to, state} when neko enters another state.
+	eventTransition = "transition"
+)
+
+// snapshot is the state of neko passed to JavaScript, with its fatigue from 0 (rested) to 1 (exhausted).
+type snapshot struct {
+	neko.State
+	Fatigue float64
+}
+
+func newSnapshot(s neko.Transition, n neko.State) snapshot {
+	return snapshot{n, neko.Fatigue(s)}
+}
+
+type listener struct {
+	id int
+	f  js.Value
+}
+
+// export sets the global API object:
+//
+//	neko.start(), neko.stop(), neko.restart()
+//	neko.state() returns the state with fatigue, or null before the first tick.
+//	neko.options() returns the options.
+//	neko.setOptions({Step: 20}) changes some options.
+//	neko.teleport(x, y) moves neko.
+//	neko.force("sleep") switches to the named state.
+//	neko.on("transition", f) subscribes to an event, "tick" or "transition".
+//
+// setOptions, teleport, force and on return promises, which are rejected on errors.  The promise of on resolves to a function that unsubscribes.
+func (c *controller) export() {
+	api := js.Global().Get("Object").New()
+	api.Set("start", js.NewCallback(func([]js.Value) { c.start() }))
+	api.Set("stop", js.NewCallback(func([]js.Value) { c.stop() }))
+	api.Set("restart", js.NewCallback(func([]js.Value) { c.restart() }))
+	api.Set("state", js.FuncOf(func(js.Value, []js.Value) interface{} {
+		if c.loop == nil || c.prev == "" {
+			return js.Null()
+		}
+		return toJSOrNull(newSnapshot(c.loop.Transition(), c.loop.State()))
+	}))
+	api.Set("options", js.FuncOf(func(js.Value, []js.Value) interface{} {
+		if c.loop == nil {
+			return js.Null()
+		}
+		return toJSOrNull(c.loop.Options())
+	}))
+	api.Set("setOptions", promiseFunc(c.setOptions))
+	api.Set("teleport", promiseFunc(c.teleport))
+	api.Set("force", promiseFunc(c.force))
+	api.Set("on", promiseFunc(c.on))
+	js.Global().Set(apiGlobal, api)
+}
+
+func (c *controller) setOptions(args []js.Value) (interface{}, error) {
+	if c.loop == nil {
+		return nil, errClientMode
+	}
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return nil, errors.New("setOptions: expected an object")
+	}
+	data := js.Global().Get("JSON").Call("stringify", args[0]).String()
+	b := c.loop.Options()
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("setOptions: %v", err)
+	}
+	prev := c.loop.Options()
+	c.loop.SetOptions(b)
+	if !sameActions(neko.ActionsFor(prev), neko.ActionsFor(b)) {
+		// The renderer loads sprites of the actions up front.
+		c.setRenderer(c.newRenderer(b))
+	}
+	return nil, nil
+}
+
+func (c *controller) teleport(args []js.Value) (interface{}, error) {
+	if c.loop == nil {
+		return nil, errClientMode
+	}
+	if len(args) < 2 || args[0].Type() != js.TypeNumber || args[1].Type() != js.TypeNumber {
+		return nil, errors.New("teleport: expected x and y numbers")
+	}
+	x, y := args[0].Float(), args[1].Float()
+	if !finite(x) || !finite(y) {
+		return nil, errors.New("teleport: expected finite x and y")
+	}
+	n := c.loop.State()
+	n.X, n.Y = x, y
+	c.loop.SetState(n)
+	return nil, nil
+}
+
+func (c *controller) force(args []js.Value) (interface{}, error) {
+	if c.loop == nil {
+		return nil, errClientMode
+	}
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return nil, errors.New("force: expected a state name")
+	}
+	s, err := neko.Force(c.loop.Transition(), args[0].String(), c.loop.State(), *c.m, c.loop.Options())
+	if err != nil {
+		return nil, err
+	}
+	c.loop.Reset(s)
+	return nil, nil
+}
+
+func (c *controller) on(args []js.Value) (interface{}, error) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeFunction {
+		return nil, errors.New("on: expected an event name and a function")
+	}
+	name := args[0].String()
+	if name != eventTick && name != eventTransition {
+		return nil, fmt.Errorf("on: unknown event %q", name)
+	}
+	if c.listeners == nil {
+		c.listeners = make(map[string][]listener)
+	}
+	c.seq++
+	id := c.seq
+	c.listeners[name] = append(c.listeners[name], listener{id, args[1]})
+
+	var off js.Func
+	off = js.FuncOf(func(js.Value, []js.Value) interface{} {
+		ls := c.listeners[name]
+		for i, l := range ls {
+			if l.id == id {
+				c.listeners[name] = append(ls[:i:i], ls[i+1:]...)
+				break
+			}
+		}
+		off.Release()
+		return nil
+	})
+	return off, nil
+}
+
+// emit calls listeners after a tick.  Listeners run in microtasks, so that the browser reports their exceptions to the console and they do not reach the simulation.
+func (c *controller) emit(s neko.Transition, n neko.State) {
+	name := neko.StateName(s)
+	prev := c.prev
+	c.prev = name
+	if len(c.listeners) == 0 {
+		return
+	}
+	state, err := toJS(newSnapshot(s, n))
+	if err != nil {
+		logError(err)
+		return
+	}
+	for _, l := range c.listeners[eventTick] {
+		l.call(state)
+	}
+	if name == prev {
+		return
+	}
+	ev := js.Global().Get("Object").New()
+	ev.Set("from", prev)
+	ev.Set("to", name)
+	ev.Set("state", state)
+	for _, l := range c.listeners[eventTransition] {
+		l.call(ev)
+	}
+}
+
+// call queues a call of the listener with the argument.
+func (l listener) call(v js.Value) {
+	js.Global().Call("queueMicrotask", l.f.Call("bind", js.Null(), v))
+}
+
+// promiseFunc returns a function that calls f with its arguments and returns a promise of its result.
+func promiseFunc(f func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		promise := js.Global().Get("Promise")
+		v, err := f(args)
+		if err != nil {
+			return promise.Call("reject", js.Global().Get("Error").New(err.Error()))
+		}
+		return promise.Call("resolve", v)
+	})
+}
+
+// toJS converts v to a JavaScript value through JSON.
+func toJS(v interface{}) (js.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return js.Null(), err
+	}
+	return js.Global().Get("JSON").Call("parse", string(data)), nil
+}
+
+// toJSOrNull is like toJS, but logs errors and returns null.
+func toJSOrNull(v interface{}) js.Value {
+	o, err := toJS(v)
+	if err != nil {
+		logError(err)
+	}
+	return o
+}
+
+// logError reports the error to the console.
+func logError(err error) {
+	js.Global().Get("console").Call("error", apiGlobal+": "+err.Error())
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/gopherjs/gopherwasm/js"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/host"
+)
+
+// apiGlobal is the global variable with the JavaScript API.  It also guards against running two copies of the script on the same page.
+const apiGlobal = "neko"
+
+// controller mounts neko into the document and runs it until stopped.  A single-page application may stop and start it again as views are mounted and unmounted.
+type controller struct {
+	doc js.Value
+	// elems are appended to the document body while running.  The first one is the element of r.
+	elems []js.Value
+	r     domRenderer
+	// newRenderer returns a renderer loading sprites of the options, and unlisten removes the snooze listener of r.
+	newRenderer func(neko.Options) domRenderer
+	unlisten    func()
+
+	// loop is the local simulation, nil in client mode.
+	loop *host.Loop
+	// reset returns the initial Transition for restarts.
+	reset func() neko.Transition
+
+	// client starts the client of a shared neko, and stopClient stops it.
+	client     func() (stop func())
+	stopClient func()
+
+	// wanted is set by start and cleared by stop, and running is set while neko is mounted.  Neko is not mounted while snoozed.
+	wanted, running bool
+	prefs           *prefs
+
+	// m is the pointer position for forced states.
+	m *neko.Pos
+	// listeners are subscribed to events by name.
+	listeners map[string][]listener
+	// seq is the id of the last listener.
+	seq int
+	// prev is the name of the state on the last tick.
+	prev string
+}
+
+// start mounts the elements and starts the loop unless neko is snoozed.  Starting a running controller does nothing.
+func (c *controller) start() {
+	c.wanted = true
+	c.sync()
+}
+
+// stop stops the loop and removes the elements.  The state is kept for the next start.
+func (c *controller) stop() {
+	c.wanted = false
+	c.sync()
+}
+
+// sync mounts or unmounts neko as wanted and allowed by the user.
+func (c *controller) sync() {
+	if c.wanted && !c.prefs.snoozed() {
+		c.mount()
+	} else {
+		c.unmount()
+	}
+}
+
+func (c *controller) mount() {
+	if c.running {
+		return
+	}
+	c.running = true
+	body := c.doc.Get("body")
+	for _, e := range c.elems {
+		body.Call("appendChild", e)
+	}
+	if c.loop != nil {
+		c.loop.Start()
+	} else {
+		c.stopClient = c.client()
+	}
+}
+
+func (c *controller) unmount() {
+	if !c.running {
+		return
+	}
+	c.running = false
+	if c.loop != nil {
+		c.loop.Stop()
+	} else {
+		c.stopClient()
+	}
+	for _, e := range c.elems {
+		if p := e.Get("parentNode"); p.Type() == js.TypeObject {
+			p.Call("removeChild", e)
+		}
+	}
+}
+
+// restart starts neko over at the initial state.
+func (c *controller) restart() {
+	c.stop()
+	if c.loop != nil {
+		c.loop.Reset(c.reset())
+	}
+	c.start()
+}
+
+// setRenderer replaces the renderer of the loop and its element.
+func (c *controller) setRenderer(r domRenderer) {
+	old := c.r.element()
+	if p := old.Get("parentNode"); p.Type() == js.TypeObject {
+		p.Call("replaceChild", r.element(), old)
+	}
+	c.elems[0] = r.element()
+	c.unlisten()
+	c.unlisten = c.prefs.listen(r.element())
+	c.r = r
+	c.loop.SetRenderer(r)
+}
+
+// startOnLoad starts the controller once the document is loaded, or right away if it already is.
+func (c *controller) startOnLoad() {
+	if c.doc.Get("readyState").String() == "complete" {
+		c.start()
+		return
+	}
+	js.Global().Get("window").Call("addEventListener", "load", js.NewEventCallback(0, func(js.Value) {
+		c.start()
+	}))
+}
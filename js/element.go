@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gopherjs/gopherwasm/js"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/host"
+)
+
+// elementName is the tag name of the custom element.
+const elementName = "neko-cat"
+
+// defaultSkin is the skin of neko without a skin attribute.
+const defaultSkin = "socks"
+
+// skins are base URLs of sprite sets by name.
+var skins = map[string]string{
+	"socks": "https://b1nary.tk/ass/webneko.net/socks/",
+}
+
+// skinFallbacks are sprites drawn instead of missing ones, by skin base URL.
+var skinFallbacks = map[string]map[neko.Action]neko.Action{
+	skins["socks"]: neko.SpriteFallbacks,
+}
+
+// presets are named changes of DefaultOptions with petting enabled, in the JSON format of Options.
+var presets = map[string]json.RawMessage{
+	"default": json.RawMessage(`{}`),
+	"lazy":    json.RawMessage(`{"Step":8,"MaxSpeed":8,"SprintSpeed":12,"StillTicks":8,"FatigueRate":0.03}`),
+	"playful": json.RawMessage(`{"Step":20,"MaxSpeed":20,"SprintSpeed":35,"StillTransition":2,"FatigueRate":0.005}`),
+	"shy":     json.RawMessage(`{"PetSpeed":4,"StartleSpeed":15}`),
+}
+
+// elementStyle keeps the element out of the page layout.  The sprite is positioned relative to the viewport.
+const elementStyle = `:host { display: block; width: 0; height: 0; }
+:host([hidden]) { display: none; }`
+
+// optionAttrs maps attribute names to Options fields, e.g. "max-speed" to MaxSpeed.  Obstacles are sampled from the document instead.
+var optionAttrs = func() map[string]string {
+	data, err := json.Marshal(neko.Options{})
+	if err != nil {
+		panic(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(err)
+	}
+	attrs := make(map[string]string)
+	for f := range fields {
+		if f != "Obstacles" {
+			attrs[kebab(f)] = f
+		}
+	}
+	return attrs
+}()
+
+// kebab converts a field name to an attribute name, e.g. "PostYawnTicks" to "post-yawn-ticks".
+func kebab(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// elementOptions returns options of the element from the preset attribute and attributes of Options fields.  Attribute values are JSON, e.g. step="20" or scratch-disable-alert="false", or strings.
+func elementOptions(e js.Value) (neko.Options, error) {
+	b := neko.DefaultOptions
+	b.HitSize = spriteSize
+	if p := e.Call("getAttribute", "preset"); p.Type() == js.TypeString {
+		patch, ok := presets[p.String()]
+		if !ok {
+			return b, fmt.Errorf("unknown preset %q", p.String())
+		}
+		if err := json.Unmarshal(patch, &b); err != nil {
+			return b, err
+		}
+	}
+
+	patch := make(map[string]json.RawMessage)
+	for attr, f := range optionAttrs {
+		v := e.Call("getAttribute", attr)
+		if v.Type() != js.TypeString {
+			continue
+		}
+		raw := json.RawMessage(v.String())
+		if !json.Valid(raw) {
+			// Bare words are strings.
+			raw, _ = json.Marshal(v.String())
+		}
+		patch[f] = raw
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return b, err
+	}
+	o := b
+	if err := json.Unmarshal(data, &o); err != nil {
+		return b, fmt.Errorf("invalid attributes: %v", err)
+	}
+	return o, nil
+}
+
+// elementSkin returns the sprite base URL of the skin attribute, a skin name or a URL ending with a slash.
+func elementSkin(e js.Value) (string, error) {
+	v := e.Call("getAttribute", "skin")
+	if v.Type() != js.TypeString {
+		return skins[defaultSkin], nil
+	}
+	if s := v.String(); strings.HasSuffix(s, "/") {
+		return s, nil
+	}
+	skin, ok := skins[v.String()]
+	if !ok {
+		return "", fmt.Errorf("unknown skin %q", v.String())
+	}
+	return skin, nil
+}
+
+// catElement is an instance of the custom element with its own simulation.
+type catElement struct {
+	e, root js.Value
+	loop    *host.Loop
+	r       domRenderer
+	prefs   *prefs
+	// off unsubscribes sync from prefs, and unlisten removes the snooze listener of the renderer.
+	off, unlisten func()
+	// attached is set while the element is connected to the document.
+	attached bool
+	// prev is the name of the state on the last tick.
+	prev string
+}
+
+// defineElement registers the custom element.  Instances chase the pointer m, run on the schedule and follow the user preferences.
+//
+// The element constructor is a plain function rather than a class, so it constructs HTMLElement itself.
+func defineElement(doc js.Value, m *neko.Pos, schedule *neko.Schedule, prefs *prefs) {
+	global := js.Global()
+	htmlElement := global.Get("HTMLElement")
+	object := global.Get("Object")
+
+	// cats are instances by the _nekoID property of elements.  Disconnected elements are dropped, and set up again when connected.
+	cats := make(map[int]*catElement)
+	seq := 0
+	cat := func(this js.Value) *catElement {
+		id := this.Get("_nekoID")
+		if id.Type() != js.TypeNumber {
+			return nil
+		}
+		return cats[id.Int()]
+	}
+	register := func(e js.Value) *catElement {
+		seq++
+		e.Set("_nekoID", seq)
+		c := &catElement{e: e, prefs: prefs}
+		cats[seq] = c
+		c.off = prefs.onChange(c.sync)
+		return c
+	}
+
+	var ctor js.Func
+	ctor = js.FuncOf(func(js.Value, []js.Value) interface{} {
+		e := global.Get("Reflect").Call("construct", htmlElement, []interface{}{}, ctor)
+		register(e)
+		return e
+	})
+
+	proto := object.Call("create", htmlElement.Get("prototype"))
+	proto.Set("constructor", ctor)
+	proto.Set("connectedCallback", js.FuncOf(func(this js.Value, _ []js.Value) interface{} {
+		c := cat(this)
+		if c == nil {
+			c = register(this)
+		}
+		c.connected(doc, m, schedule)
+		return nil
+	}))
+	proto.Set("disconnectedCallback", js.FuncOf(func(this js.Value, _ []js.Value) interface{} {
+		c := cat(this)
+		if c == nil {
+			return nil
+		}
+		c.attached = false
+		c.sync()
+		// Elements moved within the document are connected again before microtasks run.
+		id := this.Get("_nekoID").Int()
+		var drop js.Func
+		drop = js.FuncOf(func(js.Value, []js.Value) interface{} {
+			drop.Release()
+			if c, ok := cats[id]; ok && !c.attached {
+				delete(cats, id)
+				c.release()
+			}
+			return nil
+		})
+		global.Call("queueMicrotask", drop)
+		return nil
+	}))
+	proto.Set("attributeChangedCallback", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if c := cat(this); c != nil && c.loop != nil && len(args) > 0 {
+			c.attributeChanged(doc, args[0].String())
+		}
+		return nil
+	}))
+	ctor.Set("prototype", proto)
+	object.Call("setPrototypeOf", ctor, htmlElement)
+
+	observed := []interface{}{"preset", "skin", "renderer"}
+	for attr := range optionAttrs {
+		observed = append(observed, attr)
+	}
+	ctor.Set("observedAttributes", observed)
+
+	global.Get("customElements").Call("define", elementName, ctor)
+}
+
+// connected sets the simulation up on the first connection, and starts it.
+func (c *catElement) connected(doc js.Value, m *neko.Pos, schedule *neko.Schedule) {
+	c.attached = true
+	if c.loop == nil {
+		if !c.e.Call("hasAttribute", "aria-hidden").Bool() {
+			c.e.Call("setAttribute", "aria-hidden", "true")
+		}
+		b, err := elementOptions(c.e)
+		if err != nil {
+			warn(err)
+		}
+		// An element dropped on disconnection keeps its shadow root.
+		c.root = c.e.Get("shadowRoot")
+		if c.root.Type() == js.TypeObject {
+			c.root.Set("innerHTML", "")
+		} else {
+			c.root = c.e.Call("attachShadow", map[string]interface{}{"mode": "open"})
+		}
+		style := doc.Call("createElement", "style")
+		style.Set("textContent", elementStyle)
+		c.root.Call("appendChild", style)
+		c.r = c.newRenderer(doc, b)
+		c.root.Call("appendChild", c.r.element())
+
+		c.loop = host.NewLoop(schedule.Wrap(neko.NewInitialState()), b, animationFrames{}, &domInput{m: m, doc: doc, prefs: c.prefs}, c.r)
+		c.loop.BeforeTick = func(neko.Options) {
+			c.prefs.pin(c.loop)
+		}
+		c.loop.AfterTick = c.afterTick
+	}
+	c.sync()
+}
+
+// sync runs the simulation while the element is connected and neko is not snoozed.  Snoozed neko is hidden.
+func (c *catElement) sync() {
+	if c.loop == nil {
+		return
+	}
+	display := ""
+	if c.attached && !c.prefs.snoozed() {
+		c.loop.Start()
+	} else {
+		c.loop.Stop()
+		display = "none"
+	}
+	c.r.element().Get("style").Set("display", display)
+}
+
+func (c *catElement) newRenderer(doc js.Value, b neko.Options) domRenderer {
+	skin, err := elementSkin(c.e)
+	if err != nil {
+		warn(err)
+		skin = skins[defaultSkin]
+	}
+	kind := ""
+	if v := c.e.Call("getAttribute", "renderer"); v.Type() == js.TypeString {
+		kind = v.String()
+	}
+	r := newRenderer(doc, kind, skin, b)
+	if c.unlisten != nil {
+		c.unlisten()
+	}
+	c.unlisten = c.prefs.listen(r.element())
+	return r
+}
+
+// release stops the simulation and removes listeners of a disconnected element.
+func (c *catElement) release() {
+	c.off()
+	if c.loop == nil {
+		return
+	}
+	c.loop.Stop()
+	c.unlisten()
+}
+
+// attributeChanged applies a changed attribute to the running simulation.
+func (c *catElement) attributeChanged(doc js.Value, name string) {
+	b, err := elementOptions(c.e)
+	if err != nil {
+		warn(err)
+		return
+	}
+	prev := c.loop.Options()
+	c.loop.SetOptions(b)
+	if name == "skin" || name == "renderer" || !sameActions(neko.ActionsFor(prev), neko.ActionsFor(b)) {
+		// The renderer loads sprites of the actions up front.
+		r := c.newRenderer(doc, b)
+		c.root.Call("replaceChild", r.element(), c.r.element())
+		c.r = r
+		c.loop.SetRenderer(r)
+		c.sync()
+	}
+}
+
+func sameActions(a, b []neko.Action) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// afterTick plays sounds and dispatches a neko-transition event with {from, to, state} detail when neko enters another state.
+func (c *catElement) afterTick(s neko.Transition, n neko.State) {
+	if snd := neko.Sound(s); snd != "" {
+		playSound(snd)
+	}
+	name := neko.StateName(s)
+	prev := c.prev
+	c.prev = name
+	if name == prev {
+		return
+	}
+	detail := js.Global().Get("Object").New()
+	detail.Set("from", prev)
+	detail.Set("to", name)
+	detail.Set("state", toJSOrNull(newSnapshot(s, n)))
+	ev := js.Global().Get("CustomEvent").New("neko-transition", map[string]interface{}{"detail": detail})
+	c.e.Call("dispatchEvent", ev)
+}
+
+// warn logs the error to the console.
+func warn(err error) {
+	js.Global().Get("console").Call("warn", elementName+": "+err.Error())
+}
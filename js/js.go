@@ -1,82 +1,494 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gopherjs/gopherwasm/js"
 
 	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/host"
 )
 
+// obstacleSelector matches elements that neko runs around.
+const obstacleSelector = "[data-neko-obstacle], dialog[open]"
+
+// spriteSize is the width and height of the neko image, in pixels.
+const spriteSize = 32
+
 func main() {
-	n, m, b := neko.State{}, neko.Pos{}, neko.DefaultOptions
+	if js.Global().Get(apiGlobal).Type() != js.TypeUndefined {
+		// The script is loaded twice, the first copy keeps running.
+		return
+	}
+
+	m, b := neko.Pos{}, neko.DefaultOptions
+	// Neko on a page can be petted.
+	b.HitSize = spriteSize
+	// loop is the local simulation, nil in client mode.
+	var loop *host.Loop
+
+	// rec is the active recorder, and restart asks the loop to start the neko over for a new recording.
+	var rec *neko.Recorder
+	var restart bool
 
 	mouseUpdate := js.NewEventCallback(0, func(ev js.Value) {
 		m.X, m.Y = ev.Get("clientX").Float(), ev.Get("clientY").Float()
+		if rec == nil {
+			return
+		}
+		if ev.Get("type").String() == "mouseenter" {
+			rec.Enter(m)
+		} else {
+			rec.Move(m)
+		}
+	})
+
+	// Alt+Shift+R toggles recording of the session.  Stopping it downloads the recording.
+	toggleRecording := js.NewEventCallback(0, func(ev js.Value) {
+		if !ev.Get("altKey").Bool() || !ev.Get("shiftKey").Bool() || ev.Get("code").String() != "KeyR" {
+			return
+		}
+		if loop == nil {
+			return
+		}
+		if rec == nil {
+			rec = neko.NewRecorder(loop.State(), m, loop.Options())
+			restart = true
+			return
+		}
+		var buf bytes.Buffer
+		if _, err := rec.Recording().WriteTo(&buf); err == nil {
+			download("neko-recording.json", "application/json", buf.String())
+		}
+		rec = nil
+	})
+
+	// Alt+Shift+D toggles the debug panel with activity statistics.
+	stats := neko.NewStatsCollector()
+	var panel js.Value
+	toggleDebug := js.NewEventCallback(0, func(ev js.Value) {
+		if !ev.Get("altKey").Bool() || !ev.Get("shiftKey").Bool() || ev.Get("code").String() != "KeyD" {
+			return
+		}
+		style := panel.Get("style")
+		if style.Get("display").String() == "none" {
+			style.Set("display", "block")
+		} else {
+			style.Set("display", "none")
+		}
 	})
 
 	global := js.Global()
 
-	go func() {
-		image := global.Get("Image")
-		for _, a := range neko.SupportedActions {
-			img := image.New()
-			img.Set("src", imgUrl(a))
+	// Alt+Shift+N snoozes neko for a day, or wakes it, unless the user is typing.  Users who prefer reduced motion get an idle neko in the corner.
+	prefs := loadPrefs()
+	toggleSnooze := js.NewEventCallback(0, func(ev js.Value) {
+		if !ev.Get("altKey").Bool() || !ev.Get("shiftKey").Bool() || ev.Get("code").String() != "KeyN" || editable(ev) {
+			return
 		}
-	}()
+		prefs.toggle()
+	})
+
+	// game is set when the page enables game mode with the nekoGame global variable.
+	var game *neko.Game
+	var hud gameHUD
+	if v := global.Get("nekoGame"); v.Type() == js.TypeBoolean && v.Bool() {
+		game = neko.NewGame(neko.DefaultDifficulty, loadHighScore())
+	}
+
+	// The page may keep neko asleep outside working hours with the nekoAwake global variable, e.g. "09:00-18:00".
+	schedule := neko.DefaultSchedule
+	if v := global.Get("nekoAwake"); v.Type() == js.TypeString {
+		if h, err := parseHours(v.String()); err == nil {
+			schedule.Awake = &h
+		}
+	}
 
 	doc := global.Get("document")
 
 	doc.Call("addEventListener", "mousemove", mouseUpdate, false)
 	doc.Call("addEventListener", "mouseenter", mouseUpdate, false)
+	doc.Call("addEventListener", "keydown", toggleRecording, false)
+	doc.Call("addEventListener", "keydown", toggleDebug, false)
+	doc.Call("addEventListener", "keydown", toggleSnooze, false)
 
-	global.Get("window").Call("addEventListener", "load", js.NewEventCallback(0, func(js.Value) {
-		e := doc.Call("createElement", "img")
-		setupElement(e)
-		doc.Get("body").Call("appendChild", e)
-		s := neko.NewInitialState()
-		ticker := time.NewTicker(300 * time.Millisecond)
-		for {
-			s = s.Next(n, m, b)
-			n = s.Render(n, m, b)
-			displayState(e, n)
+	kind := ""
+	if v := global.Get("nekoRenderer"); v.Type() == js.TypeString {
+		kind = v.String()
+	}
+	newPageRenderer := func(b neko.Options) domRenderer {
+		return newRenderer(doc, kind, skins[defaultSkin], b)
+	}
+	r := newPageRenderer(b)
+	panel = debugPanel(doc)
+	c := &controller{doc: doc, elems: []js.Value{r.element(), panel}, r: r, newRenderer: newPageRenderer, prefs: prefs, m: &m}
+	c.unlisten = prefs.listen(r.element())
+	if game != nil {
+		hud = newGameHUD(doc, game)
+		c.elems = append(c.elems, hud.e)
+	}
 
-			select {
-			case <-ticker.C:
-				switch b.StillTransition {
-				case 0:
-					b.StillTransition = 2
-				case 1:
-					b.StillTransition = 0
-				case 2:
-					b.StillTransition = 1
+	if url := global.Get("nekoServer"); url.Type() == js.TypeString {
+		c.client = func() func() {
+			return runClient(url.String(), r, &m, b)
+		}
+	} else {
+		wrap := func(s neko.Transition) neko.Transition {
+			s = schedule.Wrap(s)
+			if game != nil {
+				s = game.Wrap(s)
+			}
+			return stats.Wrap(s)
+		}
+		c.reset = func() neko.Transition {
+			return wrap(neko.NewInitialState())
+		}
+		loop = host.NewLoop(c.reset(), b, animationFrames{}, &domInput{m: &m, doc: doc, prefs: prefs}, r)
+		loop.BeforeTick = func(b neko.Options) {
+			prefs.pin(loop)
+			if restart {
+				loop.Reset(c.reset())
+				restart = false
+			}
+			if rec != nil {
+				// Replays wrap the machine like the loop does.
+				rec.Schedule(&schedule)
+				if game != nil {
+					rec.Game(game)
 				}
-				continue
 			}
+			if rec != nil {
+				rec.Obstacles(b.Obstacles)
+				rec.Options(b)
+			}
+		}
+		loop.AfterTick = func(s neko.Transition, n neko.State) {
+			if snd := neko.Sound(s); snd != "" {
+				playSound(snd)
+			}
+			if rec != nil {
+				rec.Tick(n)
+			}
+			c.emit(s, n)
+			if game != nil {
+				hud.update(game.Score(), loop.Options().TickDuration)
+			}
+			if panel.Get("style").Get("display").String() != "none" {
+				if data, err := json.MarshalIndent(stats.Stats(), "", "  "); err == nil {
+					panel.Set("textContent", string(data))
+				}
+			}
+		}
+		c.loop = loop
+	}
+	c.export()
+	prefs.onChange(c.sync)
+	defineElement(doc, &m, &schedule, prefs)
+	// Pages with <neko-cat> elements may set the nekoAutoStart global variable to false to keep the neko of the body away.
+	if v := global.Get("nekoAutoStart"); v.Type() != js.TypeBoolean || v.Bool() {
+		c.startOnLoad()
+	}
+
+	// Callbacks run as long as the program does, and wasm programs exit when main returns.
+	select {}
+}
+
+// domInput samples the pointer position tracked by event handlers and obstacles of the document.
+type domInput struct {
+	m     *neko.Pos
+	doc   js.Value
+	prefs *prefs
+}
+
+// Pointer returns the corner where neko is pinned if the user prefers reduced motion, so that neko stays idle.
+func (in *domInput) Pointer() neko.Pos {
+	if in.prefs.reduced {
+		return corner()
+	}
+	return *in.m
+}
+
+func (in *domInput) Obstacles() []neko.Rect {
+	return obstacles(in.doc, obstacleSelector)
+}
+
+// debugPanel returns a hidden element for statistics.
+func debugPanel(doc js.Value) js.Value {
+	p := doc.Call("createElement", "pre")
+	style := p.Get("style")
+	style.Set("display", "none")
+	style.Set("position", "fixed")
+	style.Set("right", "0px")
+	style.Set("bottom", "0px")
+	style.Set("margin", "0px")
+	style.Set("padding", "4px")
+	style.Set("background", "rgba(255, 255, 255, 0.9)")
+	style.Set("font", "12px monospace")
+	style.Set("zIndex", "2147483647")
+	return p
+}
+
+// parseHours parses a "15:04-15:04" time span.
+func parseHours(s string) (neko.Hours, error) {
+	var h neko.Hours
+	i := strings.IndexByte(s, '-')
+	if i < 0 {
+		return h, fmt.Errorf("invalid hours %q", s)
+	}
+	if err := json.Unmarshal([]byte(strconv.Quote(s[:i])), &h.Start); err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal([]byte(strconv.Quote(s[i+1:])), &h.End); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// highScoreKey is the local storage key of the game high score, in ticks.
+const highScoreKey = "neko-highscore"
+
+func loadHighScore() uint {
+	v := js.Global().Get("localStorage").Call("getItem", highScoreKey)
+	if v.Type() != js.TypeString {
+		return 0
+	}
+	best, err := strconv.ParseUint(v.String(), 10, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(best)
+}
+
+// gameHUD shows the game score and a start/stop button.
+type gameHUD struct {
+	e, text, button js.Value
+	// best is the last saved high score.
+	best uint
+}
+
+func newGameHUD(doc js.Value, game *neko.Game) gameHUD {
+	h := gameHUD{
+		e:      doc.Call("createElement", "div"),
+		text:   doc.Call("createElement", "span"),
+		button: doc.Call("createElement", "button"),
+		best:   game.Score().Best,
+	}
+	style := h.e.Get("style")
+	style.Set("position", "fixed")
+	style.Set("right", "0px")
+	style.Set("top", "0px")
+	style.Set("padding", "4px")
+	style.Set("background", "rgba(255, 255, 255, 0.9)")
+	style.Set("font", "12px monospace")
+	style.Set("zIndex", "2147483647")
+
+	h.button.Set("textContent", "Start")
+	h.button.Get("style").Set("marginLeft", "8px")
+	h.button.Call("addEventListener", "click", js.NewEventCallback(0, func(js.Value) {
+		if game.Score().Running {
+			game.Stop()
+			h.button.Set("textContent", "Start")
+		} else {
+			game.Start()
+			h.button.Set("textContent", "Stop")
 		}
 	}))
+
+	h.e.Call("appendChild", h.text)
+	h.e.Call("appendChild", h.button)
+	return h
+}
+
+func (h *gameHUD) update(sc neko.Score, tick time.Duration) {
+	seconds := func(ticks uint) string {
+		return strconv.FormatFloat((time.Duration(ticks)*tick).Seconds(), 'f', 1, 64) + "s"
+	}
+	h.text.Set("textContent", "Survived "+seconds(sc.Survival)+" · Best "+seconds(sc.Best)+
+		" · Caught "+strconv.FormatUint(uint64(sc.Catches), 10)+" · Level "+strconv.FormatUint(uint64(sc.Level), 10))
+	if sc.Best > h.best {
+		h.best = sc.Best
+		js.Global().Get("localStorage").Call("setItem", highScoreKey, strconv.FormatUint(uint64(sc.Best), 10))
+	}
+}
+
+// obstacles returns bounding rectangles of visible elements matching the selector.  Rectangles are extended to the top and left by the sprite size since neko is positioned by its top-left corner.
+func obstacles(doc js.Value, selector string) []neko.Rect {
+	var rs []neko.Rect
+	es := doc.Call("querySelectorAll", selector)
+	for i, l := 0, es.Length(); i < l; i++ {
+		r := es.Index(i).Call("getBoundingClientRect")
+		w, h := r.Get("width").Float(), r.Get("height").Float()
+		if w == 0 || h == 0 {
+			continue
+		}
+		x, y := r.Get("left").Float(), r.Get("top").Float()
+		rs = append(rs, neko.Rect{
+			Min: neko.Pos{X: x - spriteSize, Y: y - spriteSize},
+			Max: neko.Pos{X: x + w, Y: y + h},
+		})
+	}
+	return rs
+}
+
+// message mirrors server.Message without pulling the server package into the wasm binary.
+type message struct {
+	Type   string      `json:"type"`
+	ID     string      `json:"id,omitempty"`
+	Pos    *neko.Pos   `json:"pos,omitempty"`
+	State  *neko.State `json:"state,omitempty"`
+	Target string      `json:"target,omitempty"`
+}
+
+// Reconnection delays of the client double from minBackoff up to maxBackoff.
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// runClient renders the neko simulated by the server at url, and sends pointer updates to it once per tick.  It tells the server when the pointer leaves the page, and reconnects with backoff when the connection is lost.  The returned function closes the connection.
+func runClient(url string, r host.Renderer, m *neko.Pos, b neko.Options) (stop func()) {
+	global := js.Global()
+	var ws js.Value
+	// left is set when the pointer leaves the page until it moves again.
+	left := false
+	// sent is the last position sent to the server.
+	var sent neko.Pos
+
+	send := func(msg message) {
+		if ws.Type() != js.TypeObject || ws.Get("readyState").Int() != 1 { // OPEN
+			return
+		}
+		if data, err := json.Marshal(msg); err == nil {
+			ws.Call("send", string(data))
+		}
+	}
+	leave := js.NewEventCallback(0, func(js.Value) {
+		if !left {
+			left = true
+			send(message{Type: "leave"})
+		}
+	})
+	doc, window := global.Get("document"), global.Get("window")
+	doc.Call("addEventListener", "mouseleave", leave, false)
+	window.Call("addEventListener", "pagehide", leave, false)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(b.TickDuration)
+		defer ticker.Stop()
+		backoff := minBackoff
+		for {
+			opened, closed := make(chan struct{}), make(chan struct{})
+			onOpen := js.NewEventCallback(0, func(js.Value) { close(opened) })
+			onClose := js.NewEventCallback(0, func(js.Value) { close(closed) })
+			onMessage := js.NewEventCallback(0, func(ev js.Value) {
+				var msg message
+				if err := json.Unmarshal([]byte(ev.Get("data").String()), &msg); err != nil {
+					return
+				}
+				if msg.Type == "state" && msg.State != nil {
+					r.Draw(*msg.State)
+				}
+			})
+			ws = global.Get("WebSocket").New(url)
+			ws.Set("onopen", onOpen)
+			ws.Set("onclose", onClose)
+			ws.Set("onmessage", onMessage)
+
+			stopped := false
+			for connected := true; connected; {
+				select {
+				case <-done:
+					stopped, connected = true, false
+				case <-closed:
+					connected = false
+				case <-opened:
+					opened = nil
+					backoff = minBackoff
+					// The new connection knows nothing about the pointer.
+					sent = neko.Pos{}
+					if left {
+						send(message{Type: "leave"})
+					} else {
+						sent = *m
+						send(message{Type: "pointer", Pos: &sent})
+					}
+				case <-ticker.C:
+					if *m == sent {
+						continue
+					}
+					left = false
+					sent = *m
+					send(message{Type: "pointer", Pos: &sent})
+				}
+			}
+			// Handlers are detached before they are released, since the socket may still fire events.
+			for _, h := range []string{"onopen", "onclose", "onmessage"} {
+				ws.Set(h, js.Null())
+			}
+			if stopped {
+				ws.Call("close")
+			}
+			onOpen.Release()
+			onClose.Release()
+			onMessage.Release()
+			if stopped {
+				return
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		doc.Call("removeEventListener", "mouseleave", leave, false)
+		window.Call("removeEventListener", "pagehide", leave, false)
+		leave.Release()
+	}
 }
 
-func setupElement(e js.Value) {
-	e.Set("draggable", false)
-	styles := e.Get("style")
-	styles.Set("position", "fixed")
-	styles.Set("width", "32px")
-	styles.Set("top", "0px")
-	styles.Set("left", "0px")
-	styles.Set("imageRendering", "pixelated")
+// playSound plays the named sound from the nekoSounds base URL, e.g. "https://example.com/sounds/" serving "purr.ogg".  Sounds are off unless the page sets nekoSounds.
+func playSound(name string) {
+	base := js.Global().Get("nekoSounds")
+	if base.Type() != js.TypeString {
+		return
+	}
+	audio := js.Global().Get("Audio").New(base.String() + name + ".ogg")
+	// Browsers reject playback before the first user interaction, the returned promise is ignored.
+	audio.Call("play")
 }
 
-func displayState(e js.Value, n neko.State) {
-	style := e.Get("style")
-	style.Set("left", f2px(n.X))
-	style.Set("top", f2px(n.Y))
-	e.Set("src", imgUrl(n.Action))
+// download saves the data as a file.
+func download(name, mime, data string) {
+	global := js.Global()
+	url := global.Get("URL")
+	blob := global.Get("Blob").New([]interface{}{data}, map[string]interface{}{"type": mime})
+	href := url.Call("createObjectURL", blob)
+	a := global.Get("document").Call("createElement", "a")
+	a.Set("href", href)
+	a.Set("download", name)
+	a.Call("click")
+	url.Call("revokeObjectURL", href)
 }
 
-func imgUrl(a neko.Action) neko.Action {
-	return "https://b1nary.tk/ass/webneko.net/socks/" + a + ".gif"
+// imgUrl returns the URL of the action sprite in the skin with the base URL.
+func imgUrl(skin string, a neko.Action) string {
+	if f, ok := skinFallbacks[skin][a]; ok {
+		a = f
+	}
+	return skin + string(a) + ".gif"
 }
 
 func f2px(f float64) string {
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gopherjs/gopherwasm/js"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/host"
+)
+
+// snoozeKey is the local storage key of the time until which neko is snoozed, in Unix milliseconds.
+const snoozeKey = "neko-snoozed-until"
+
+// snoozeDuration is how long a double click on neko or Alt+Shift+N keeps it away.
+const snoozeDuration = 24 * time.Hour
+
+// prefs are preferences of the user: reduced motion and snoozing.
+type prefs struct {
+	// reduced is set when the user prefers reduced motion.  Neko then stays in the bottom right corner, idle.
+	reduced bool
+	// until is the end of the snooze.
+	until time.Time
+	// timer wakes neko at the end of the snooze.
+	timer *time.Timer
+	// changed are called when neko is snoozed or woken.
+	changed []changeListener
+	seq     int
+}
+
+type changeListener struct {
+	id int
+	f  func()
+}
+
+// loadPrefs reads the preferences and tracks changes of the reduced motion media query, and snoozes in other tabs.
+func loadPrefs() *prefs {
+	p := &prefs{}
+	global := js.Global()
+	if mm := global.Get("matchMedia"); mm.Type() == js.TypeFunction {
+		mq := global.Call("matchMedia", "(prefers-reduced-motion: reduce)")
+		p.reduced = mq.Get("matches").Bool()
+		update := js.NewEventCallback(0, func(ev js.Value) {
+			p.reduced = ev.Get("matches").Bool()
+		})
+		if mq.Get("addEventListener").Type() == js.TypeFunction {
+			mq.Call("addEventListener", "change", update)
+		} else {
+			// Older Safari.
+			mq.Call("addListener", update)
+		}
+	}
+
+	p.until = parseUntil(global.Get("localStorage").Call("getItem", snoozeKey))
+	p.wakeAt(p.until)
+
+	// Storage events come from other tabs only.  A null key means the storage was cleared.
+	global.Call("addEventListener", "storage", js.NewEventCallback(0, func(ev js.Value) {
+		if k := ev.Get("key"); k.Type() == js.TypeString && k.String() != snoozeKey {
+			return
+		}
+		p.until = parseUntil(ev.Get("newValue"))
+		p.wakeAt(p.until)
+		p.notify()
+	}))
+	return p
+}
+
+// parseUntil parses the stored end of a snooze.  Missing or invalid values are the zero time.
+func parseUntil(v js.Value) time.Time {
+	if v.Type() != js.TypeString {
+		return time.Time{}
+	}
+	ms, err := strconv.ParseInt(v.String(), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// snoozed reports whether neko is snoozed.
+func (p *prefs) snoozed() bool {
+	return time.Now().Before(p.until)
+}
+
+// onChange calls f when neko is snoozed or woken, until the returned function is called.
+func (p *prefs) onChange(f func()) (off func()) {
+	p.seq++
+	id := p.seq
+	p.changed = append(p.changed, changeListener{id, f})
+	return func() {
+		for i, l := range p.changed {
+			if l.id == id {
+				p.changed = append(p.changed[:i:i], p.changed[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// snooze keeps neko away for the duration, or wakes it with zero duration.  The end of the snooze is kept in local storage.
+func (p *prefs) snooze(d time.Duration) {
+	p.until = time.Now().Add(d)
+	storage := js.Global().Get("localStorage")
+	if d > 0 {
+		storage.Call("setItem", snoozeKey, strconv.FormatInt(p.until.UnixNano()/int64(time.Millisecond), 10))
+	} else {
+		storage.Call("removeItem", snoozeKey)
+	}
+	p.wakeAt(p.until)
+	p.notify()
+}
+
+// toggle snoozes awake neko and wakes snoozed one.
+func (p *prefs) toggle() {
+	if p.snoozed() {
+		p.snooze(0)
+	} else {
+		p.snooze(snoozeDuration)
+	}
+}
+
+func (p *prefs) wakeAt(t time.Time) {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if d := time.Until(t); d > 0 {
+		p.timer = time.AfterFunc(d, p.notify)
+	}
+}
+
+func (p *prefs) notify() {
+	for _, l := range p.changed {
+		l.f()
+	}
+}
+
+// listen snoozes neko on double clicks on the element, until the returned function is called.
+func (p *prefs) listen(e js.Value) (release func()) {
+	cb := js.NewEventCallback(js.PreventDefault, func(js.Value) {
+		p.snooze(snoozeDuration)
+	})
+	e.Call("addEventListener", "dblclick", cb)
+	return func() {
+		e.Call("removeEventListener", "dblclick", cb)
+		cb.Release()
+	}
+}
+
+// editable reports whether the event target takes text input, e.g. an input or a contenteditable element, so that key presses are typing rather than shortcuts.
+func editable(ev js.Value) bool {
+	target := ev.Get("target")
+	if ev.Get("composedPath").Type() == js.TypeFunction {
+		// The target within shadow roots.
+		if path := ev.Call("composedPath"); path.Length() > 0 {
+			target = path.Index(0)
+		}
+	}
+	if target.Type() != js.TypeObject {
+		return false
+	}
+	if ce := target.Get("isContentEditable"); ce.Type() == js.TypeBoolean && ce.Bool() {
+		return true
+	}
+	tag := target.Get("tagName")
+	if tag.Type() != js.TypeString {
+		return false
+	}
+	switch tag.String() {
+	case "INPUT", "TEXTAREA", "SELECT":
+		return true
+	}
+	return false
+}
+
+// corner returns the position of neko in the bottom right corner of the viewport.
+func corner() neko.Pos {
+	w := js.Global().Get("window")
+	return neko.Pos{
+		X: w.Get("innerWidth").Float() - spriteSize,
+		Y: w.Get("innerHeight").Float() - spriteSize,
+	}
+}
+
+// pin keeps neko of the loop in the corner while the user prefers reduced motion.  It is called before each tick.
+func (p *prefs) pin(l *host.Loop) {
+	if !p.reduced {
+		return
+	}
+	n, c := l.State(), corner()
+	n.X, n.Y = c.X, c.Y
+	l.SetState(n)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gopherjs/gopherwasm/js"
+)
+
+// animationFrames is a host.Scheduler on browser animation frames.  Callbacks run on the first frame at least the given duration from now, so browsers throttle hidden pages.
+type animationFrames struct{}
+
+func (animationFrames) Now() time.Time {
+	return time.Now()
+}
+
+func (animationFrames) After(d time.Duration, f func()) func() {
+	global := js.Global()
+	due := time.Now().Add(d)
+
+	var id js.Value
+	var cb js.Callback
+	done := false
+	cb = js.NewCallback(func([]js.Value) {
+		if time.Now().Before(due) {
+			id = global.Call("requestAnimationFrame", cb)
+			return
+		}
+		done = true
+		cb.Release()
+		f()
+	})
+	id = global.Call("requestAnimationFrame", cb)
+
+	return func() {
+		if done {
+			return
+		}
+		done = true
+		global.Call("cancelAnimationFrame", id)
+		cb.Release()
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gopherjs/gopherwasm/js"
+
+	neko "github.com/tie/dummyneko"
+	"github.com/tie/dummyneko/host"
+)
+
+// domRenderer is a host.Renderer that draws into an element of the document.
+type domRenderer interface {
+	host.Renderer
+	element() js.Value
+}
+
+// newRenderer returns a renderer of the kind, "canvas" (default) or "img", drawing sprites from the skin base URL.  Sprites of the actions of b are loaded up front.
+func newRenderer(doc js.Value, kind, skin string, b neko.Options) domRenderer {
+	as := neko.ActionsFor(b)
+	if kind == "img" {
+		return newImgRenderer(doc, skin, as)
+	}
+	return newCanvasRenderer(doc, skin, as)
+}
+
+// setupElement positions the sprite element and hides it from assistive technology.
+func setupElement(e js.Value) {
+	e.Set("draggable", false)
+	e.Call("setAttribute", "aria-hidden", "true")
+	styles := e.Get("style")
+	styles.Set("position", "fixed")
+	styles.Set("width", f2px(spriteSize))
+	styles.Set("top", "0px")
+	styles.Set("left", "0px")
+	styles.Set("imageRendering", "pixelated")
+}
+
+// imgRenderer shows neko as an img element with the sprite of the action.
+type imgRenderer struct {
+	e    js.Value
+	skin string
+	// a is the displayed action.
+	a neko.Action
+}
+
+// newImgRenderer returns an imgRenderer and preloads sprites of the actions.  Browsers may still cancel downloads when the source changes before they finish.
+func newImgRenderer(doc js.Value, skin string, as []neko.Action) *imgRenderer {
+	image := js.Global().Get("Image")
+	for _, a := range as {
+		img := image.New()
+		img.Set("src", imgUrl(skin, a))
+	}
+	e := doc.Call("createElement", "img")
+	setupElement(e)
+	return &imgRenderer{e: e, skin: skin}
+}
+
+func (r *imgRenderer) element() js.Value {
+	return r.e
+}
+
+// Draw implements host.Renderer.
+func (r *imgRenderer) Draw(n neko.State) {
+	style := r.e.Get("style")
+	style.Set("left", f2px(n.X))
+	style.Set("top", f2px(n.Y))
+	if n.Action != r.a {
+		r.e.Set("src", imgUrl(r.skin, n.Action))
+		r.a = n.Action
+	}
+}
+
+// canvasRenderer draws sprites of neko on a canvas element.  All sprites are loaded and decoded up front, and the canvas stays hidden until they are, so changing the action never starts a download.
+type canvasRenderer struct {
+	e, ctx js.Value
+	// scale is the number of canvas pixels per sprite pixel, for crisp sprites on high density displays.
+	scale int
+	imgs  map[neko.Action]js.Value
+	// pending is the number of sprites that are not settled yet.
+	pending int
+	// drawn is the action on the canvas, empty if none.
+	drawn neko.Action
+}
+
+func newCanvasRenderer(doc js.Value, skin string, as []neko.Action) *canvasRenderer {
+	scale := int(math.Ceil(js.Global().Get("devicePixelRatio").Float()))
+	if scale < 1 {
+		scale = 1
+	}
+	e := doc.Call("createElement", "canvas")
+	setupElement(e)
+	e.Get("style").Set("height", f2px(spriteSize))
+	e.Get("style").Set("visibility", "hidden")
+	e.Set("width", spriteSize*scale)
+	e.Set("height", spriteSize*scale)
+	r := &canvasRenderer{
+		e:       e,
+		ctx:     e.Call("getContext", "2d"),
+		scale:   scale,
+		imgs:    make(map[neko.Action]js.Value),
+		pending: len(as),
+	}
+
+	image := js.Global().Get("Image")
+	for _, a := range as {
+		a := a
+		img := image.New()
+		img.Set("src", imgUrl(skin, a))
+		r.imgs[a] = img
+		// Only one of the callbacks fires, and it releases both.
+		var decoded, failed js.Callback
+		release := func() {
+			img.Set("onload", js.Null())
+			img.Set("onerror", js.Null())
+			decoded.Release()
+			failed.Release()
+		}
+		decoded = js.NewCallback(func([]js.Value) {
+			release()
+			r.settle()
+		})
+		failed = js.NewCallback(func([]js.Value) {
+			release()
+			// A broken sprite is drawn as nothing rather than keeping neko hidden forever.
+			delete(r.imgs, a)
+			r.settle()
+		})
+		if img.Get("decode").Type() == js.TypeFunction {
+			img.Call("decode").Call("then", decoded, failed)
+		} else {
+			img.Set("onload", decoded)
+			img.Set("onerror", failed)
+		}
+	}
+	return r
+}
+
+// settle counts a loaded or failed sprite and shows the canvas once all are.
+func (r *canvasRenderer) settle() {
+	r.pending--
+	if r.pending == 0 {
+		r.e.Get("style").Set("visibility", "visible")
+	}
+}
+
+func (r *canvasRenderer) element() js.Value {
+	return r.e
+}
+
+// Draw implements host.Renderer.
+func (r *canvasRenderer) Draw(n neko.State) {
+	style := r.e.Get("style")
+	style.Set("left", f2px(n.X))
+	style.Set("top", f2px(n.Y))
+	if r.pending > 0 || n.Action == r.drawn {
+		return
+	}
+
+	size := spriteSize * r.scale
+	// Context state is reset when the canvas is resized, so the smoothing is turned off on each draw.
+	r.ctx.Set("imageSmoothingEnabled", false)
+	r.ctx.Call("clearRect", 0, 0, size, size)
+	if img, ok := r.imgs[n.Action]; ok {
+		r.ctx.Call("drawImage", img, 0, 0, size, size)
+	}
+	r.drawn = n.Action
+}
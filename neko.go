@@ -2,6 +2,7 @@ package dummyneko
 
 import (
 	"math"
+	"time"
 )
 
 type State struct {
@@ -38,6 +39,55 @@ type Options struct {
 	ScratchTicks, ScratchCount, PostScratchTicks uint
 	// Disable transition from Scratch to Alert state
 	ScratchDisableAlert bool
+
+	// Coordinates is the coordinate system of neko, pointer and obstacle positions.
+	Coordinates Coordinates
+
+	// Number of directions in run and scratch sprite sets, see Sector and Compass functions.  Zero values mean DefaultRunDirections and DefaultScratchDirections.
+	RunDirections, ScratchDirections uint
+
+	// Speed dynamics of Run state, in pixels per tick.  Zero values keep neko running at constant Step.
+	//
+	// Acceleration is the speed gained per tick until the top speed is reached.
+	Acceleration float64
+	// MaxSpeed is the top speed, defaults to Step.
+	MaxSpeed float64
+	// SprintSpeed is the top speed while the pointer is farther than SprintDistance.
+	SprintSpeed, SprintDistance float64
+	// Deceleration is the speed lost per tick when slowing down, e.g. when approaching the pointer.
+	Deceleration float64
+
+	// Fatigue model.  Fatigue grows from 0 (rested) to 1 (exhausted) while neko runs, and recovers in states with Rest, e.g. sleep.  Tired neko runs slower, top speeds are scaled down by half of its fatigue.  Zero values disable the model.
+	//
+	// FatigueRate is the fatigue gained per tick of running.
+	FatigueRate float64
+	// RecoveryRate is the fatigue lost per tick of rest.
+	RecoveryRate float64
+	// TiredFatigue is the fatigue from which "tired" edge conditions hold, e.g. neko gives up chasing and goes to sleep.  They keep holding until the fatigue falls below RestedFatigue, so tired neko sleeps until it is rested.
+	TiredFatigue float64
+	// RestedFatigue is the fatigue below which "rested" edge conditions hold, e.g. neko itches rather than yawns.
+	RestedFatigue float64
+
+	// Petting.  Slow pointer moves over the sprite of idle or sleeping neko make it purr, and fast ones startle it.  Pointer speeds are in pixels per tick.
+	//
+	// HitSize is the width and height of the sprite area at the neko position, see Coordinates.SpriteRect.  Zero, the default, disables petting.
+	HitSize float64
+	// PetSpeed is the top speed of petting pointer moves.
+	PetSpeed float64
+	// StartleSpeed is the speed above which pointer moves over the sprite startle neko.  Zero disables startling.
+	StartleSpeed float64
+	// ticks of Purr state
+	PurrTicks, PurrCount uint
+
+	// TickDuration is the duration of a single tick, used for animation timing.  Defaults to DefaultTickDuration.
+	TickDuration time.Duration
+	// Animations override animations of action kinds (see Animate function).  Actions of directed kinds are given without direction, e.g. "run3".
+	Animations map[Kind]Animation
+
+	// Obstacles are rectangles that neko runs around instead of walking over them.
+	Obstacles []Rect
+	// ObstacleMargin is the clearance kept between neko and obstacles.
+	ObstacleMargin float64
 }
 
 type Action string
@@ -50,6 +100,8 @@ const (
 	ActionItch2     = "itch2"
 	ActionSleep1    = "sleep1"
 	ActionSleep2    = "sleep2"
+	ActionPurr1     = "purr1"
+	ActionPurr2     = "purr2"
 	ActionNRun1     = "nrun1"
 	ActionNRun2     = "nrun2"
 	ActionNERun1    = "nerun1"
@@ -76,6 +128,12 @@ const (
 	ActionWScratch2 = "wscratch2"
 )
 
+// SpriteFallbacks map actions missing from the webneko.net sprite sets to sprites drawn instead, e.g. purring neko is drawn asleep.  Hosts drawing those sets look sprites of these actions up under the fallback names.
+var SpriteFallbacks = map[Action]Action{
+	ActionPurr1: ActionSleep1,
+	ActionPurr2: ActionSleep2,
+}
+
 // SupportedActions is a list of implemented action.
 var SupportedActions = []Action{
 	ActionAlert,
@@ -87,6 +145,9 @@ var SupportedActions = []Action{
 	// sleep
 	ActionSleep1,
 	ActionSleep2,
+	// purr
+	ActionPurr1,
+	ActionPurr2,
 	// run
 	ActionNRun1,
 	ActionNRun2,
@@ -147,155 +208,46 @@ var DefaultOptions = Options{
 	ScratchCount:        4,
 	PostScratchTicks:    4,
 	ScratchDisableAlert: true,
+
+	Acceleration:   5,
+	MaxSpeed:       15,
+	SprintSpeed:    25,
+	SprintDistance: 300,
+	Deceleration:   5,
+
+	FatigueRate:   0.01,
+	RecoveryRate:  0.02,
+	TiredFatigue:  0.8,
+	RestedFatigue: 0.2,
+
+	PetSpeed:     8,
+	StartleSpeed: 40,
+	PurrTicks:    2,
+	PurrCount:    6,
+
+	TickDuration: DefaultTickDuration,
 }
 
 const (
 	π = math.Pi
 )
 
-// runAction returns action name for Run state given the direction (see direction function) and whether the action is even.
-func runAction(d dir, even bool) Action {
-	// Return values are hardcoded because go tool gives better coverage reports this way.
-	switch d {
-	case dirN:
-		if even {
-			return ActionNRun2
-		} else {
-			return ActionNRun1
-		}
-	case dirNE:
-		if even {
-			return ActionNERun2
-		} else {
-			return ActionNERun1
-		}
-	case dirE:
-		if even {
-			return ActionERun2
-		} else {
-			return ActionERun1
-		}
-	case dirSE:
-		if even {
-			return ActionSERun2
-		} else {
-			return ActionSERun1
-		}
-	case dirS:
-		if even {
-			return ActionSRun2
-		} else {
-			return ActionSRun1
-		}
-	case dirSW:
-		if even {
-			return ActionSWRun2
-		} else {
-			return ActionSWRun1
-		}
-	case dirW:
-		if even {
-			return ActionWRun2
-		} else {
-			return ActionWRun1
-		}
-	case dirNW:
-		if even {
-			return ActionNWRun2
-		} else {
-			return ActionNWRun1
-		}
-	}
-	return ""
-}
-
-// scratchAction returns action name for Scratch state given the major direction (see majorDirection function) and whether the action is even.
-func scratchAction(d dir, even bool) Action {
-	switch d {
-	case dirN:
-		if even {
-			return ActionNScratch2
-		} else {
-			return ActionNScratch1
-		}
-	case dirE:
-		if even {
-			return ActionEScratch2
-		} else {
-			return ActionEScratch1
-		}
-	case dirS:
-		if even {
-			return ActionSScratch2
-		} else {
-			return ActionSScratch1
-		}
-	case dirW:
-		if even {
-			return ActionWScratch2
-		} else {
-			return ActionWScratch1
-		}
-	}
-	return ""
-}
-
 // direction function computes the compass direction at the (x,y) to the destination (mx,my) coordinates.
 //
 // Return values are: E, SE, S, SW, W, NW, N, NE.
 //
-// Note that it assumes a coordinate system with inverted Y axis, i.e. with origin at the top-left corner.  Invoke it with inverted ordinate sign to use the classic cartesian coordinate system.
+// Note that it assumes a coordinate system with inverted Y axis, i.e. with origin at the top-left corner.  States use the coordinate system from Options instead.
 func direction(x, y, mx, my float64) dir {
-	// Return values are hardcoded because go tool gives better coverage reports this way.
-	dx := x - mx
-	dy := y - my
-	α := math.Atan2(dy, dx)
-	switch {
-	case -π*8/8 <= α && α <= -π*7/8:
-		return dirE
-	case -π*7/8 <= α && α <= -π*5/8:
-		return dirSE
-	case -π*5/8 <= α && α <= -π*3/8:
-		return dirS
-	case -π*3/8 <= α && α <= -π*1/8:
-		return dirSW
-	case -π*1/8 <= α && α <= +π*1/8:
-		return dirW
-	case +π*1/8 <= α && α <= +π*3/8:
-		return dirNW
-	case +π*3/8 <= α && α <= +π*5/8:
-		return dirN
-	case +π*5/8 <= α && α <= +π*7/8:
-		return dirNE
-	case +π*7/8 <= α && α <= +π*8/8:
-		return dirE
-	}
-	return ""
+	return facing(ScreenCoordinates, 8, x, y, mx, my)
 }
 
 // majorDirection function computes the major compass direction at the (x,y) to the destination (mx,my) coordinates.
 //
 // The major compass directions are: E, S, W, N.
 //
-// Note that it assumes a coordinate system with inverted Y axis, i.e. with origin at the top-left corner.  Invoke it with inverted ordinate sign to use the classic cartesian coordinate system.
+// Note that it assumes a coordinate system with inverted Y axis, i.e. with origin at the top-left corner.  States use the coordinate system from Options instead.
 func majorDirection(x, y, mx, my float64) dir {
-	// Return values are hardcoded because go tool gives better coverage reports this way.
-	dx := x - mx
-	dy := y - my
-	α := math.Atan2(dy, dx)
-	switch {
-	case -π*4/4 <= α && α <= -π*3/4:
-		return dirE
-	case -π*3/4 <= α && α <= -π*1/4:
-		return dirS
-	case -π*1/4 <= α && α <= +π*1/4:
-		return dirW
-	case +π*1/4 <= α && α <= +π*3/4:
-		return dirN
-	case +π*3/4 <= α && α <= +π*4/4:
-		return dirE
-	}
-	return ""
+	return facing(ScreenCoordinates, 4, x, y, mx, my)
 }
 
 func pointerNearby(n State, m Pos, b Options) bool {
@@ -305,12 +257,12 @@ func pointerNearby(n State, m Pos, b Options) bool {
 	return d <= b.Dmax
 }
 
-func makeStep(n *State, m Pos, b Options) {
+func makeStep(n *State, m Pos, step float64) {
 	dx := n.X - m.X
 	dy := n.Y - m.Y
 	d := math.Hypot(dx, dy)
 	if d > 0 {
-		dstep := b.Step / d
+		dstep := step / d
 		n.X -= dstep * dx
 		n.Y -= dstep * dy
 		return
@@ -323,255 +275,18 @@ type Transition interface {
 	Render(State, Pos, Options) State
 }
 
-func NewInitialState() Transition {
-	return initialState{}
-}
-
-type initialState struct{}
-
-func (initialState) Next(n State, m Pos, b Options) Transition {
-	return stateStill{}
-}
-
-func (initialState) Render(n State, m Pos, b Options) State {
-	return n
-}
-
-type stateStill struct {
-	tick uint
-}
-
-func (s stateStill) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.StillTicks {
-		return s.selectNext(n, m, b)
-	}
-	return s
-}
-
-func (s stateStill) selectNext(n State, m Pos, b Options) Transition {
-	switch b.StillTransition {
-	case 1:
-		return stateItch{}
-	case 2:
-		return stateScratch{}
-	}
-	return stateYawn{}
-}
-
-func (s stateStill) Render(n State, m Pos, b Options) State {
-	n.Action = ActionStill
-	return n
-}
-
-type stateItch struct {
-	tick  uint
-	even  bool
-	count uint
-}
-
-func (s stateItch) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.ItchTicks {
-		s.tick = 0
-		s.even = !s.even
-		s.count += 1
-	}
-	if s.count >= b.ItchCount {
-		return statePostItch{}
-	}
-	return s
-}
-
-func (s stateItch) Render(n State, m Pos, b Options) State {
-	if s.even {
-		n.Action = ActionItch2
-	} else {
-		n.Action = ActionItch1
-	}
-	return n
-}
-
-type statePostItch struct {
-	tick uint
-}
-
-func (s statePostItch) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.PostItchTicks {
-		return stateYawn{}
-	}
-	return s
-}
-
-func (s statePostItch) Render(n State, m Pos, b Options) State {
-	n.Action = ActionStill
-	return n
-}
-
-type stateScratch struct {
-	tick  uint
-	even  bool
-	count uint
-}
-
-func (s stateScratch) Next(n State, m Pos, b Options) Transition {
-	if !b.ScratchDisableAlert && !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.ScratchTicks {
-		s.tick = 0
-		s.even = !s.even
-		s.count += 1
-	}
-	if s.count >= b.ScratchCount {
-		return statePostScratch{}
-	}
-	return s
-}
-
-func (s stateScratch) Render(n State, m Pos, b Options) State {
-	d := majorDirection(n.X, n.Y, m.X, m.Y)
-	n.Action = scratchAction(d, s.even)
-	return n
-}
-
-type statePostScratch struct {
-	tick uint
-}
-
-func (s statePostScratch) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.PostScratchTicks {
-		return stateYawn{}
-	}
-	return s
-}
-
-func (s statePostScratch) Render(n State, m Pos, b Options) State {
-	n.Action = ActionStill
-	return n
-}
+// defaultMachine is the compiled DefaultBehavior.
+var defaultMachine = mustCompile(&DefaultBehavior)
 
-type stateYawn struct {
-	tick uint
-}
-
-func (s stateYawn) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.YawnTicks {
-		return statePostYawn{}
+func mustCompile(bh *Behavior) *machine {
+	m, err := compileBehavior(bh)
+	if err != nil {
+		panic(err)
 	}
-	return s
+	return m
 }
 
-func (s stateYawn) Render(n State, m Pos, b Options) State {
-	n.Action = ActionYawn
-	return n
-}
-
-type statePostYawn struct {
-	tick uint
-}
-
-func (s statePostYawn) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.PostYawnTicks {
-		return stateSleep{}
-	}
-	return s
-}
-
-func (s statePostYawn) Render(n State, m Pos, b Options) State {
-	n.Action = ActionStill
-	return n
-}
-
-type stateSleep struct {
-	tick uint
-	even bool
-}
-
-func (s stateSleep) Next(n State, m Pos, b Options) Transition {
-	if !pointerNearby(n, m, b) {
-		return stateAlert{}
-	}
-	s.tick += 1
-	if s.tick >= b.SleepTicks {
-		s.tick = 0
-		s.even = !s.even
-	}
-	return s
-}
-
-func (s stateSleep) Render(n State, m Pos, b Options) State {
-	if s.even {
-		n.Action = ActionSleep2
-	} else {
-		n.Action = ActionSleep1
-	}
-	return n
-}
-
-type stateAlert struct {
-	tick uint
-}
-
-func (s stateAlert) Next(n State, m Pos, b Options) Transition {
-	if pointerNearby(n, m, b) {
-		return stateStill{}
-	}
-	s.tick += 1
-	if s.tick >= b.AlertTicks {
-		return stateRun{}
-	}
-	return s
-}
-
-func (s stateAlert) Render(n State, m Pos, b Options) State {
-	n.Action = ActionAlert
-	return n
-}
-
-type stateRun struct {
-	tick uint
-	even bool
-}
-
-func (s stateRun) Next(n State, m Pos, b Options) Transition {
-	if pointerNearby(n, m, b) {
-		return stateStill{}
-	}
-	s.tick += 1
-	if s.tick >= b.RunTicks {
-		s.tick = 0
-		s.even = !s.even
-	}
-	return s
-}
-
-func (s stateRun) Render(n State, m Pos, b Options) State {
-	d := direction(n.X, n.Y, m.X, m.Y)
-	n.Action = runAction(d, s.even)
-	makeStep(&n, m, b)
-	return n
+// NewInitialState returns the initial Transition of DefaultBehavior.
+func NewInitialState() Transition {
+	return behaviorStart{defaultMachine}
 }
@@ -0,0 +1,225 @@
+// Package nekotest implements a conformance suite for neko Transition implementations.
+//
+// It drives a Transition with random pointer paths and Options and checks that the implementation behaves like a neko:
+//
+//   - Next never returns nil;
+//   - Render only produces supported actions;
+//   - positions never become NaN or infinite;
+//   - the neko reaches a stationary pointer within a bounded number of ticks;
+//   - the neko eventually falls asleep when left alone.
+package nekotest
+
+import (
+	"math"
+	"math/rand"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// T is the subset of testing.TB used by the suite.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Config is a configuration of the suite.  Zero values select defaults.
+type Config struct {
+	// Seed of the random source.
+	Seed int64
+	// Runs is the number of random runs per check, defaults to 100.
+	Runs int
+	// Ticks is the number of ticks of each random pointer path, defaults to 500.
+	Ticks int
+	// CatchTicks bounds the number of ticks to reach a stationary pointer, defaults to 1000.  The bound is stretched for options with the fatigue model, see catchTicks.
+	CatchTicks int
+	// SleepTicks bounds the number of ticks to fall asleep when left alone, defaults to 1000.
+	SleepTicks int
+	// Options returns random options for a run, defaults to RandomOptions.
+	Options func(*rand.Rand) neko.Options
+	// Actions returns actions that may be rendered with the options, defaults to neko.ActionsFor.
+	Actions func(neko.Options) []neko.Action
+	// Asleep reports whether the action is a sleeping one, defaults to sleep1 and sleep2.
+	Asleep func(neko.Action) bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Runs == 0 {
+		c.Runs = 100
+	}
+	if c.Ticks == 0 {
+		c.Ticks = 500
+	}
+	if c.CatchTicks == 0 {
+		c.CatchTicks = 1000
+	}
+	if c.SleepTicks == 0 {
+		c.SleepTicks = 1000
+	}
+	if c.Options == nil {
+		c.Options = RandomOptions
+	}
+	if c.Actions == nil {
+		c.Actions = neko.ActionsFor
+	}
+	if c.Asleep == nil {
+		c.Asleep = func(a neko.Action) bool {
+			return a == neko.ActionSleep1 || a == neko.ActionSleep2
+		}
+	}
+	return c
+}
+
+// RandomOptions returns DefaultOptions with randomized timings and speeds.
+//
+// Dmax is kept at least half of the top speed, otherwise a neko overshooting the pointer may never catch it.
+func RandomOptions(r *rand.Rand) neko.Options {
+	b := neko.DefaultOptions
+	ticks := func() uint {
+		return uint(r.Intn(8))
+	}
+
+	b.StillTransition = uint(r.Intn(3))
+	b.StillTicks = ticks()
+	b.YawnTicks, b.PostYawnTicks = ticks(), ticks()
+	b.SleepTicks = ticks()
+	b.AlertTicks = ticks()
+	b.RunTicks = ticks()
+	b.ItchTicks, b.ItchCount, b.PostItchTicks = ticks(), ticks(), ticks()
+	b.ScratchTicks, b.ScratchCount, b.PostScratchTicks = ticks(), ticks(), ticks()
+	b.ScratchDisableAlert = r.Intn(2) == 0
+
+	b.Step = 1 + 29*r.Float64()
+	b.MaxSpeed, b.Acceleration, b.Deceleration = 0, 0, 0
+	b.SprintSpeed, b.SprintDistance = 0, 0
+	if r.Intn(2) == 0 {
+		b.MaxSpeed = b.Step * (1 + r.Float64())
+		b.Acceleration = b.MaxSpeed * r.Float64()
+		b.Deceleration = b.MaxSpeed * r.Float64()
+		b.SprintSpeed = b.MaxSpeed * (1 + r.Float64())
+		b.SprintDistance = b.SprintSpeed * (2 + 10*r.Float64())
+	}
+
+	top := math.Max(b.Step, b.MaxSpeed)
+	b.Dmax = top * (0.5 + 1.5*r.Float64())
+	return b
+}
+
+// Run runs the conformance suite over Transitions created by newState.
+func Run(t T, newState func() neko.Transition, c Config) {
+	t.Helper()
+	c = c.withDefaults()
+	r := rand.New(rand.NewSource(c.Seed))
+	for i := 0; i < c.Runs; i++ {
+		b := c.Options(r)
+		if !checkInvariants(t, newState, c, r, b) {
+			return
+		}
+		if !checkCatch(t, newState, c, r, b) {
+			return
+		}
+		if !checkSleep(t, newState, c, b) {
+			return
+		}
+	}
+}
+
+// pointerPath returns a random pointer path.  The pointer stays still, wanders slowly or jumps around, e.g. when it leaves and enters the window.
+func pointerPath(r *rand.Rand, ticks int) []neko.Pos {
+	var m neko.Pos
+	ms := make([]neko.Pos, ticks)
+	for i := range ms {
+		switch p := r.Float64(); {
+		case p < 0.05:
+			m = neko.Pos{X: 2000 * r.Float64(), Y: 2000 * r.Float64()}
+		case p < 0.5:
+			m.X += 20 * r.NormFloat64()
+			m.Y += 20 * r.NormFloat64()
+		}
+		ms[i] = m
+	}
+	return ms
+}
+
+func finite(n neko.State) bool {
+	return !math.IsNaN(n.X) && !math.IsNaN(n.Y) && !math.IsInf(n.X, 0) && !math.IsInf(n.Y, 0)
+}
+
+func checkInvariants(t T, newState func() neko.Transition, c Config, r *rand.Rand, b neko.Options) bool {
+	t.Helper()
+	supported := make(map[neko.Action]bool)
+	for _, a := range c.Actions(b) {
+		supported[a] = true
+	}
+
+	var n neko.State
+	s := newState()
+	for i, m := range pointerPath(r, c.Ticks) {
+		s = s.Next(n, m, b)
+		if s == nil {
+			t.Errorf("tick %d: Next returned nil with options %+v", i, b)
+			return false
+		}
+		n = s.Render(n, m, b)
+		if !finite(n) {
+			t.Errorf("tick %d: Render returned position (%f, %f) with options %+v", i, n.X, n.Y, b)
+			return false
+		}
+		if !supported[n.Action] {
+			t.Errorf("tick %d: Render returned unsupported action %q with options %+v", i, n.Action, b)
+			return false
+		}
+	}
+	return true
+}
+
+func checkCatch(t T, newState func() neko.Transition, c Config, r *rand.Rand, b neko.Options) bool {
+	t.Helper()
+	m := neko.Pos{X: 2000 * r.Float64(), Y: 2000 * r.Float64()}
+
+	var n neko.State
+	s := newState()
+	ticks := catchTicks(c.CatchTicks, b)
+	for i := 0; i < ticks; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if math.Hypot(n.X-m.X, n.Y-m.Y) <= b.Dmax {
+			return true
+		}
+	}
+	t.Errorf("neko did not reach pointer at %v in %d ticks, stopped at (%f, %f) with options %+v", m, ticks, n.X, n.Y, b)
+	return false
+}
+
+// catchTicks stretches the bound of ticks to reach the pointer for tiring neko.  Tired neko runs at half of its speed at worst, and rests for FatigueRate/RecoveryRate of the time it runs.  Each rest takes also a round of idle states, and neko speeds up from a standstill again after it.
+func catchTicks(ticks int, b neko.Options) int {
+	if b.FatigueRate <= 0 || b.TiredFatigue <= 0 {
+		return ticks
+	}
+	running := 2 * float64(ticks)
+	if b.RecoveryRate <= 0 {
+		return int(running)
+	}
+	resting := running * b.FatigueRate / b.RecoveryRate
+	rests := running*b.FatigueRate/math.Max(b.TiredFatigue-b.RestedFatigue, b.FatigueRate) + 1
+	idle := float64(b.StillTicks + b.YawnTicks + b.PostYawnTicks + b.SleepTicks + b.AlertTicks + 5)
+	if b.Acceleration > 0 {
+		idle += math.Max(b.Step, b.MaxSpeed) / b.Acceleration
+	}
+	return int(running + resting + rests*idle)
+}
+
+func checkSleep(t T, newState func() neko.Transition, c Config, b neko.Options) bool {
+	t.Helper()
+	var n neko.State
+	s := newState()
+	for i := 0; i < c.SleepTicks; i++ {
+		m := neko.Pos{X: n.X, Y: n.Y}
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if c.Asleep(n.Action) {
+			return true
+		}
+	}
+	t.Errorf("neko left alone did not fall asleep in %d ticks, last action %q with options %+v", c.SleepTicks, n.Action, b)
+	return false
+}
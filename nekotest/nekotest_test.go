@@ -0,0 +1,75 @@
+package nekotest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	neko "github.com/tie/dummyneko"
+)
+
+func TestDefaultBehavior(t *testing.T) {
+	Run(t, neko.NewInitialState, Config{})
+}
+
+func TestCustomBehavior(t *testing.T) {
+	bh := neko.DefaultBehavior
+	bh.Initial = "sleep"
+	Run(t, func() neko.Transition {
+		s, err := neko.NewBehaviorState(&bh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}, Config{Seed: 1, Runs: 20})
+}
+
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// broken is a Transition that misbehaves in the configured way.
+type broken struct {
+	nilNext bool
+	action  neko.Action
+	x       float64
+}
+
+func (s broken) Next(n neko.State, m neko.Pos, b neko.Options) neko.Transition {
+	if s.nilNext {
+		return nil
+	}
+	return s
+}
+
+func (s broken) Render(n neko.State, m neko.Pos, b neko.Options) neko.State {
+	n.X = s.x
+	n.Action = s.action
+	return n
+}
+
+func TestBrokenTransitions(t *testing.T) {
+	cases := []struct {
+		s   broken
+		err string
+	}{
+		{broken{nilNext: true}, "Next returned nil"},
+		{broken{action: "dance1"}, `tick 0: Render returned unsupported action "dance1"`},
+		{broken{action: neko.ActionStill, x: math.Inf(1)}, "Render returned position (+Inf, 0.000000)"},
+		{broken{action: neko.ActionStill}, "did not reach pointer"},
+	}
+	for _, c := range cases {
+		var r recorder
+		Run(&r, func() neko.Transition { return c.s }, Config{Runs: 1})
+		if len(r.errors) != 1 || !strings.Contains(r.errors[0], c.err) {
+			t.Errorf("%+v expected error %q, got %q", c.s, c.err, r.errors)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package dummyneko
+
+import (
+	"math"
+)
+
+// Rect is an axis-aligned rectangle given by its Min and Max corners.
+//
+// Corners are defined by minimal and maximal coordinates rather than sides, so a Rect means the same area in both screen and cartesian coordinates: Min is the top-left corner on screen and the bottom-left one in cartesian coordinates.
+type Rect struct {
+	Min, Max Pos
+}
+
+// pathEpsilon is the distance by which path corners are pushed away from obstacles, so that a path running along an obstacle edge does not count as crossing it.
+const pathEpsilon = 1e-6
+
+func (r Rect) contains(p Pos) bool {
+	return r.Min.X < p.X && p.X < r.Max.X && r.Min.Y < p.Y && p.Y < r.Max.Y
+}
+
+func (r Rect) inflate(d float64) Rect {
+	r.Min.X -= d
+	r.Min.Y -= d
+	r.Max.X += d
+	r.Max.Y += d
+	return r
+}
+
+func (r Rect) corners() [4]Pos {
+	return [4]Pos{
+		{r.Min.X, r.Min.Y},
+		{r.Max.X, r.Min.Y},
+		{r.Max.X, r.Max.Y},
+		{r.Min.X, r.Max.Y},
+	}
+}
+
+// crosses reports whether the segment from a to b passes through the interior of r.  Segments touching only the edges of r do not cross it.
+//
+// It uses Liang–Barsky clipping.
+func (r Rect) crosses(a, b Pos) bool {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b.X-a.X, b.Y-a.Y
+	clip := func(p, q float64) bool {
+		if p == 0 {
+			return q > 0
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return false
+			}
+			if t > t0 {
+				t0 = t
+			}
+		} else {
+			if t < t0 {
+				return false
+			}
+			if t < t1 {
+				t1 = t
+			}
+		}
+		return true
+	}
+	if !clip(-dx, a.X-r.Min.X) ||
+		!clip(+dx, r.Max.X-a.X) ||
+		!clip(-dy, a.Y-r.Min.Y) ||
+		!clip(+dy, r.Max.Y-a.Y) {
+		return false
+	}
+	return t0 < t1
+}
+
+func distance(a, b Pos) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// plannedPath is a path planned with planPath and its arguments.
+type plannedPath struct {
+	a, m      Pos
+	obstacles []Rect
+	margin    float64
+	path      []Pos
+}
+
+// plan returns p if it was planned for the same ends and obstacles, and plans a new path otherwise.  p may be nil.
+func (p *plannedPath) plan(a, m Pos, b Options) *plannedPath {
+	if p != nil && p.a == a && p.m == m && p.margin == b.ObstacleMargin && rectsEqual(p.obstacles, b.Obstacles) {
+		return p
+	}
+	return &plannedPath{
+		a:         a,
+		m:         m,
+		obstacles: append([]Rect(nil), b.Obstacles...),
+		margin:    b.ObstacleMargin,
+		path:      planPath(a, m, b),
+	}
+}
+
+// planPath returns the waypoints of the shortest path from a to m that avoids the obstacles in Options.  The last waypoint is always m.
+//
+// Obstacles containing either end of the path are ignored, so that the neko is never trapped by an element that appears on top of it or of the pointer.  If m is unreachable, the path is a straight line.
+func planPath(a, m Pos, b Options) []Pos {
+	var obstacles []Rect
+	for _, r := range b.Obstacles {
+		r = r.inflate(b.ObstacleMargin)
+		if r.contains(a) || r.contains(m) {
+			continue
+		}
+		obstacles = append(obstacles, r)
+	}
+
+	visible := func(p, q Pos) bool {
+		for _, r := range obstacles {
+			if r.crosses(p, q) {
+				return false
+			}
+		}
+		return true
+	}
+
+	direct := []Pos{m}
+	if visible(a, m) {
+		return direct
+	}
+
+	// Shortest paths around convex obstacles only bend at obstacle corners, so run Dijkstra's algorithm over the visibility graph of the corners.
+	nodes := []Pos{a, m}
+	for _, r := range obstacles {
+	corners:
+		for _, c := range r.inflate(pathEpsilon).corners() {
+			for _, o := range obstacles {
+				if o.contains(c) {
+					continue corners
+				}
+			}
+			nodes = append(nodes, c)
+		}
+	}
+
+	const src, dst = 0, 1
+	dist := make([]float64, len(nodes))
+	prev := make([]int, len(nodes))
+	done := make([]bool, len(nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[src] = 0
+	for {
+		u := -1
+		for i := range nodes {
+			if !done[i] && !math.IsInf(dist[i], 1) && (u < 0 || dist[i] < dist[u]) {
+				u = i
+			}
+		}
+		if u < 0 {
+			return direct
+		}
+		if u == dst {
+			break
+		}
+		done[u] = true
+		for v := range nodes {
+			if done[v] {
+				continue
+			}
+			d := dist[u] + distance(nodes[u], nodes[v])
+			if d < dist[v] && visible(nodes[u], nodes[v]) {
+				dist[v] = d
+				prev[v] = u
+			}
+		}
+	}
+
+	var path []Pos
+	for v := dst; v != src; v = prev[v] {
+		path = append(path, nodes[v])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// followPath moves the neko along the path by step.  Intermediate waypoints are passed through, the last one is approached with makeStep.
+func followPath(n *State, path []Pos, step float64) {
+	for len(path) > 1 {
+		d := distance(Pos{n.X, n.Y}, path[0])
+		if d > step {
+			break
+		}
+		n.X, n.Y = path[0].X, path[0].Y
+		step -= d
+		path = path[1:]
+	}
+	makeStep(n, path[0], step)
+}
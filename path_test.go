@@ -0,0 +1,147 @@
+package dummyneko
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRectCrosses(t *testing.T) {
+	r := Rect{Min: Pos{0, 0}, Max: Pos{10, 10}}
+	cases := []struct {
+		a, b Pos
+		e    bool
+	}{
+		{Pos{-5, 5}, Pos{15, 5}, true},
+		{Pos{5, -5}, Pos{5, 15}, true},
+		{Pos{-5, -5}, Pos{15, 15}, true},
+		{Pos{-5, 5}, Pos{-1, 5}, false},
+		{Pos{-5, -5}, Pos{15, -5}, false},
+		{Pos{-5, 0}, Pos{15, 0}, false}, // along the edge
+		{Pos{5, 5}, Pos{5, 6}, true},    // inside
+		{Pos{-5, 10}, Pos{5, 20}, false},
+	}
+	for _, c := range cases {
+		if got := r.crosses(c.a, c.b); got != c.e {
+			t.Errorf("%v.crosses(%v, %v) expected %v, got %v", r, c.a, c.b, c.e, got)
+		}
+	}
+}
+
+func TestPlanPath(t *testing.T) {
+	wall := Rect{Min: Pos{40, -10}, Max: Pos{60, 30}}
+	cases := []struct {
+		a, m Pos
+		b    Options
+		e    []Pos
+	}{
+		{ // no obstacles
+			Pos{0, 0}, Pos{100, 0},
+			Options{},
+			[]Pos{{100, 0}},
+		},
+		{ // obstacle not in the way
+			Pos{0, 0}, Pos{100, 0},
+			Options{Obstacles: []Rect{{Min: Pos{40, 10}, Max: Pos{60, 30}}}},
+			[]Pos{{100, 0}},
+		},
+		{ // around the top of the wall
+			Pos{0, 0}, Pos{100, 0},
+			Options{Obstacles: []Rect{wall}},
+			[]Pos{{40, -10}, {60, -10}, {100, 0}},
+		},
+		{ // around the top of the wall with margin
+			Pos{0, 0}, Pos{100, 0},
+			Options{Obstacles: []Rect{wall}, ObstacleMargin: 5},
+			[]Pos{{35, -15}, {65, -15}, {100, 0}},
+		},
+		{ // pointer inside the obstacle
+			Pos{0, 0}, Pos{50, 0},
+			Options{Obstacles: []Rect{wall}},
+			[]Pos{{50, 0}},
+		},
+		{ // neko inside the obstacle
+			Pos{50, 0}, Pos{100, 0},
+			Options{Obstacles: []Rect{wall}},
+			[]Pos{{100, 0}},
+		},
+		{ // pointer enclosed by obstacles
+			Pos{0, 0}, Pos{100, 0},
+			Options{Obstacles: []Rect{
+				{Min: Pos{80, -30}, Max: Pos{120, -10}},
+				{Min: Pos{80, 10}, Max: Pos{120, 30}},
+				{Min: Pos{70, -30}, Max: Pos{90, 30}},
+				{Min: Pos{110, -30}, Max: Pos{130, 30}},
+			}},
+			[]Pos{{100, 0}},
+		},
+	}
+	for _, c := range cases {
+		path := planPath(c.a, c.m, c.b)
+		if len(path) != len(c.e) {
+			t.Errorf("planPath(%v, %v) expected %v, got %v", c.a, c.m, c.e, path)
+			continue
+		}
+		for i := range path {
+			if distance(path[i], c.e[i]) > 1e-3 {
+				t.Errorf("planPath(%v, %v) expected %v, got %v", c.a, c.m, c.e, path)
+				break
+			}
+		}
+	}
+}
+
+func TestFollowPath(t *testing.T) {
+	n := State{}
+	path := []Pos{{3, 0}, {3, 10}}
+	followPath(&n, path, 5)
+	if math.Abs(n.X-3) > 1e-9 || math.Abs(n.Y-2) > 1e-9 {
+		t.Errorf("expected neko at (3, 2), got (%f, %f)", n.X, n.Y)
+	}
+}
+
+func TestRunAroundObstacle(t *testing.T) {
+	b := Options{
+		Step:      5,
+		Dmax:      1,
+		Obstacles: []Rect{{Min: Pos{10, -20}, Max: Pos{20, 20}}},
+	}
+	m := Pos{X: 30, Y: 0}
+
+	var n State
+	s := NewInitialState()
+	for i := 0; i < 100 && !pointerNearby(n, m, b); i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if b.Obstacles[0].contains(Pos{n.X, n.Y}) {
+			t.Fatalf("neko walked into the obstacle at (%f, %f)", n.X, n.Y)
+		}
+	}
+	if !pointerNearby(n, m, b) {
+		t.Errorf("neko did not reach the pointer, stopped at (%f, %f)", n.X, n.Y)
+	}
+}
+
+func TestPlannedPath(t *testing.T) {
+	b := Options{Obstacles: []Rect{{Min: Pos{10, -20}, Max: Pos{20, 20}}}}
+	a, m := Pos{}, Pos{X: 30}
+
+	var p *plannedPath
+	p = p.plan(a, m, b)
+	if q := p.plan(a, m, b); q != p {
+		t.Error("expected the path to be reused for the same ends and obstacles")
+	}
+
+	// Hosts may sample obstacles into a new slice on each tick.
+	b.Obstacles = append([]Rect(nil), b.Obstacles...)
+	if q := p.plan(a, m, b); q != p {
+		t.Error("expected the path to be reused for equal obstacles")
+	}
+
+	b.Obstacles[0].Max.Y = 30
+	if q := p.plan(a, m, b); q == p {
+		t.Error("expected a new path for changed obstacles")
+	}
+	if q := p.plan(a, Pos{X: 40}, b); q == p {
+		t.Error("expected a new path for a moved pointer")
+	}
+}
@@ -0,0 +1,81 @@
+package dummyneko
+
+// memory is the state of neko kept across behavior states.
+type memory struct {
+	fatigue float64
+	// exhausted is set when fatigue reaches TiredFatigue until it falls below RestedFatigue.
+	exhausted bool
+	// pointer position on the last tick
+	pointer Pos
+	tracked bool
+}
+
+// track remembers the pointer position and returns the pointer speed since the last tick.
+func (mem *memory) track(m Pos) float64 {
+	var speed float64
+	if mem.tracked {
+		speed = distance(mem.pointer, m)
+	}
+	mem.pointer, mem.tracked = m, true
+	return speed
+}
+
+// untrack returns s that forgets the pointer position, so that the next pointer move does not count toward its speed.
+func untrack(s Transition) Transition {
+	switch t := s.(type) {
+	case node:
+		t.mem.tracked = false
+		return t
+	case wrapper:
+		return t.rewrap(untrack(t.unwrap()))
+	}
+	return s
+}
+
+// overSprite reports whether the pointer is over the hit area of neko.
+func overSprite(n State, m Pos, b Options) bool {
+	if b.HitSize <= 0 {
+		return false
+	}
+	r := b.Coordinates.SpriteRect(Pos{n.X, n.Y}, b.HitSize)
+	return m.X >= r.Min.X && m.X <= r.Max.X && m.Y >= r.Min.Y && m.Y <= r.Max.Y
+}
+
+// pointerGuard reports whether a pointer guard holds for the pointer moving at the speed.
+func pointerGuard(g Guard, n State, m Pos, speed float64, b Options) bool {
+	switch g {
+	case GuardNear:
+		return pointerNearby(n, m, b)
+	case GuardFar:
+		return !pointerNearby(n, m, b)
+	case GuardPet:
+		return overSprite(n, m, b) && speed > 0 && speed <= b.PetSpeed
+	case GuardStartle:
+		return overSprite(n, m, b) && b.StartleSpeed > 0 && speed > b.StartleSpeed
+	case GuardLeave:
+		return !overSprite(n, m, b)
+	}
+	return false
+}
+
+// possible reports whether the guard may hold with the given Options.
+func (g Guard) possible(b Options) bool {
+	switch g {
+	case GuardPet:
+		return b.HitSize > 0 && b.PetSpeed > 0
+	case GuardStartle:
+		return b.HitSize > 0 && b.StartleSpeed > 0
+	}
+	return true
+}
+
+// Sound returns the sound of the behavior state s if s has just entered it, e.g. "purr".  Hosts play it once.
+func Sound(s Transition) string {
+	if t, ok := s.(wrapper); ok {
+		return Sound(t.unwrap())
+	}
+	if s, ok := s.(node); ok && s.entered {
+		return s.def().Sound
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestOverSprite(t *testing.T) {
+	n := State{X: 10, Y: 20}
+	cases := []struct {
+		m    Pos
+		size float64
+		c    Coordinates
+		e    bool
+	}{
+		{Pos{10, 20}, 32, ScreenCoordinates, true},
+		{Pos{42, 52}, 32, ScreenCoordinates, true},
+		{Pos{25, 30}, 32, ScreenCoordinates, true},
+		{Pos{9, 30}, 32, ScreenCoordinates, false},
+		{Pos{25, 53}, 32, ScreenCoordinates, false},
+		{Pos{10, 20}, 0, ScreenCoordinates, false},
+		// The sprite lies below the position on screen, toward negative Y.
+		{Pos{25, 10}, 32, CartesianCoordinates, true},
+		{Pos{42, -12}, 32, CartesianCoordinates, true},
+		{Pos{25, 30}, 32, CartesianCoordinates, false},
+		{Pos{25, -13}, 32, CartesianCoordinates, false},
+	}
+	for _, c := range cases {
+		if v := overSprite(n, c.m, Options{HitSize: c.size, Coordinates: c.c}); v != c.e {
+			t.Errorf("overSprite(%v, %v) with size %v and coordinates %d: expected %v, got %v", n, c.m, c.size, c.c, c.e, v)
+		}
+	}
+}
+
+func TestPetting(t *testing.T) {
+	b := Options{
+		Step:         10,
+		Dmax:         50,
+		HitSize:      32,
+		PetSpeed:     5,
+		StartleSpeed: 20,
+		PurrTicks:    1,
+		PurrCount:    3,
+	}
+	n := State{}
+	m := Pos{X: 10, Y: 10}
+
+	s, err := Force(NewInitialState(), "sleep", n, m, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = s.Next(n, m, b)
+
+	// The pointer rests over neko, which is not petting.
+	if s = s.Next(n, m, b); StateName(s) != "sleep" {
+		t.Fatalf("expected resting pointer to keep neko asleep, got %q", StateName(s))
+	}
+
+	var names, sounds []string
+	for i := 0; i < 5; i++ {
+		m.X += 3
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		names = append(names, StateName(s))
+		sounds = append(sounds, Sound(s))
+	}
+	e := []string{"purr", "purr", "purr", "sleep", "purr"}
+	es := []string{"purr", "", "", "", "purr"}
+	for i := range e {
+		if names[i] != e[i] || sounds[i] != es[i] {
+			t.Fatalf("expected states %v and sounds %q, got %v and %q", e, es, names, sounds)
+		}
+	}
+	if n.Action != ActionPurr1 && n.Action != ActionPurr2 {
+		t.Errorf("expected purr action, got %q", n.Action)
+	}
+
+	// The pointer slowly leaves the sprite.
+	m = Pos{X: 34, Y: 10}
+	if s = s.Next(n, m, b); StateName(s) != "still" {
+		t.Errorf("expected neko to stop purring, got %q", StateName(s))
+	}
+}
+
+func TestStartle(t *testing.T) {
+	b := Options{
+		Step:         10,
+		Dmax:         50,
+		HitSize:      32,
+		PetSpeed:     5,
+		StartleSpeed: 20,
+		AlertTicks:   1,
+	}
+	n := State{}
+
+	s, err := Force(NewInitialState(), "yawn", n, Pos{X: 1, Y: 30}, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = s.Next(n, Pos{X: 1, Y: 30}, b)
+	s = s.Next(n, Pos{X: 31, Y: 1}, b)
+	if StateName(s) != "startled" || Sound(s) != "startle" {
+		t.Fatalf("expected neko to be startled, got %q with sound %q", StateName(s), Sound(s))
+	}
+	if n = s.Render(n, Pos{X: 31, Y: 1}, b); n.Action != ActionAlert {
+		t.Errorf("expected alert action, got %q", n.Action)
+	}
+
+	b.StartleSpeed = 0
+	s, _ = Force(s, "yawn", n, Pos{X: 1, Y: 30}, b)
+	s = s.Next(n, Pos{X: 1, Y: 30}, b)
+	if s = s.Next(n, Pos{X: 31, Y: 1}, b); StateName(s) == "startled" {
+		t.Error("expected startling to be disabled")
+	}
+}
+
+func TestPettingGraph(t *testing.T) {
+	reachable := func(b *Options) map[string]bool {
+		g, err := DefaultBehavior.Graph(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		states := make(map[string]bool)
+		for _, s := range g.States {
+			states[s] = true
+		}
+		return states
+	}
+
+	b := DefaultOptions
+	b.HitSize = 32
+	if s := reachable(&b); !s["purr"] || !s["startled"] {
+		t.Errorf("expected purr and startled states with petting enabled, got %v", s)
+	}
+	if s := reachable(&DefaultOptions); s["purr"] || s["startled"] {
+		t.Errorf("expected petting to be disabled with default options, got %v", s)
+	}
+}
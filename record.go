@@ -0,0 +1,307 @@
+package dummyneko
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RecordingVersion is the version of the recording format written by Recorder.
+const RecordingVersion = 1
+
+// EventType is a type of recorded input event.
+type EventType string
+
+const (
+	// EventMove is a pointer move.
+	EventMove EventType = "move"
+	// EventEnter is the pointer entering the page.
+	EventEnter EventType = "enter"
+	// EventObstacles replaces obstacles in Options.
+	EventObstacles EventType = "obstacles"
+	// EventOptions changes Options.
+	EventOptions EventType = "options"
+	// EventTick is a state machine tick.  It holds the rendered State.
+	EventTick EventType = "tick"
+)
+
+// Event is a timestamped input event.
+type Event struct {
+	// Time since the start of recording.
+	Time time.Duration `json:"t"`
+	Type EventType     `json:"type"`
+	// Pos is the pointer position of move and enter events.
+	Pos *Pos `json:"pos,omitempty"`
+	// Obstacles of obstacles events.
+	Obstacles []Rect `json:"obstacles,omitempty"`
+	// Options of options events, a JSON object with changed Options fields.
+	Options json.RawMessage `json:"options,omitempty"`
+	// State rendered on tick events.
+	State *State `json:"state,omitempty"`
+	// Level of the game on tick events, see Recording.Difficulty.
+	Level uint `json:"level,omitempty"`
+}
+
+// Recording is a session of input events.  Replaying it with the same Transition yields the same states.
+//
+// Recordings start with a fresh Transition, e.g. the one returned from NewInitialState, so hosts restart the neko when they start recording.  Hosts that wrap the Transition with a Schedule or a Game record them too, and Replay wraps the given Transition the same way.
+type Recording struct {
+	Version int `json:"version"`
+	// Start is the time of the start of recording.  Replays of a schedule take the time of day of ticks from it.
+	Start time.Time `json:"start"`
+	// Schedule that wrapped the recorded Transition, if any.
+	Schedule *Schedule `json:"schedule,omitempty"`
+	// Difficulty of the game that wrapped the recorded Transition, if any.  Tick events hold the game level.
+	Difficulty *Difficulty `json:"difficulty,omitempty"`
+	// Options at the start of recording.
+	Options Options `json:"options"`
+	// Pointer position at the start of recording.
+	Pointer Pos `json:"pointer"`
+	// Neko state at the start of recording.
+	State  State   `json:"state"`
+	Events []Event `json:"events"`
+}
+
+// ReadRecording reads a JSON-encoded Recording.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	var rec Recording
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return nil, err
+	}
+	if rec.Version != RecordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", rec.Version)
+	}
+	return &rec, nil
+}
+
+// WriteTo writes the JSON-encoded recording to w.
+func (rec *Recording) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Replay feeds the recorded events into the Transition s and returns states rendered on each tick.
+func (rec *Recording) Replay(s Transition) []State {
+	n, m, b := rec.State, rec.Pointer, rec.Options
+	var now time.Time
+	if rec.Schedule != nil {
+		sc := *rec.Schedule
+		sc.Clock = ClockFunc(func() time.Time { return now })
+		s = sc.Wrap(s)
+	}
+	var states []State
+	for _, e := range rec.Events {
+		switch e.Type {
+		case EventMove, EventEnter:
+			if e.Pos != nil {
+				m = *e.Pos
+			}
+		case EventObstacles:
+			b.Obstacles = e.Obstacles
+		case EventOptions:
+			b = patchOptions(b, e.Options)
+		case EventTick:
+			now = rec.Start.Add(e.Time)
+			tb := b
+			if rec.Difficulty != nil {
+				tb = rec.Difficulty.options(tb, e.Level)
+			}
+			s = s.Next(n, m, tb)
+			n = s.Render(n, m, tb)
+			states = append(states, n)
+		}
+	}
+	return states
+}
+
+// diffOptions returns a JSON object with fields of b that differ from the ones of a.
+func diffOptions(a, b Options) json.RawMessage {
+	fa, fb := optionsFields(a), optionsFields(b)
+	diff := make(map[string]json.RawMessage)
+	for k, v := range fb {
+		if string(fa[k]) != string(v) {
+			diff[k] = v
+		}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// patchOptions returns b with fields replaced by the ones from the JSON object.
+func patchOptions(b Options, patch json.RawMessage) Options {
+	fields := optionsFields(b)
+	var diff map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &diff); err != nil {
+		return b
+	}
+	for k, v := range diff {
+		fields[k] = v
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		panic(err)
+	}
+	var o Options
+	if err := json.Unmarshal(data, &o); err != nil {
+		return b
+	}
+	return o
+}
+
+func optionsFields(b Options) map[string]json.RawMessage {
+	data, err := json.Marshal(b)
+	if err != nil {
+		panic(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(err)
+	}
+	return fields
+}
+
+// Verify replays the recording and checks that rendered states match the recorded ones.
+func (rec *Recording) Verify(s Transition) error {
+	states := rec.Replay(s)
+	i := 0
+	for _, e := range rec.Events {
+		if e.Type != EventTick {
+			continue
+		}
+		if e.State != nil && *e.State != states[i] {
+			return fmt.Errorf("tick %d at %v: recorded %+v, replayed %+v", i, e.Time, *e.State, states[i])
+		}
+		i++
+	}
+	return nil
+}
+
+// Recorder records input events.
+type Recorder struct {
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+
+	rec   Recording
+	start time.Time
+	// last recorded options
+	b Options
+
+	schedule *Schedule
+	game     *Game
+}
+
+// NewRecorder returns a Recorder starting with the given state, pointer position and options.
+func NewRecorder(n State, m Pos, b Options) *Recorder {
+	r := &Recorder{
+		rec: Recording{
+			Version: RecordingVersion,
+			Options: b,
+			Pointer: m,
+			State:   n,
+		},
+		b: b,
+	}
+	r.start = r.now()
+	return r
+}
+
+func (r *Recorder) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+func (r *Recorder) record(e Event) {
+	e.Time = r.now().Sub(r.start)
+	r.rec.Events = append(r.rec.Events, e)
+}
+
+// Move records a pointer move.
+func (r *Recorder) Move(m Pos) {
+	r.record(Event{Type: EventMove, Pos: &m})
+}
+
+// Enter records the pointer entering the page.
+func (r *Recorder) Enter(m Pos) {
+	r.record(Event{Type: EventEnter, Pos: &m})
+}
+
+// Obstacles records obstacles if they changed.
+func (r *Recorder) Obstacles(obstacles []Rect) {
+	if rectsEqual(r.b.Obstacles, obstacles) {
+		return
+	}
+	r.b.Obstacles = append([]Rect(nil), obstacles...)
+	r.record(Event{Type: EventObstacles, Obstacles: r.b.Obstacles})
+}
+
+func rectsEqual(a, b []Rect) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Options records options if they changed.  Only changed fields are recorded, and changes of obstacles are recorded with obstacles events.
+func (r *Recorder) Options(b Options) {
+	b.Obstacles = r.b.Obstacles
+	diff := diffOptions(r.b, b)
+	if diff == nil {
+		return
+	}
+	r.b = b
+	r.record(Event{Type: EventOptions, Options: diff})
+}
+
+// Schedule records the schedule that wraps the Transition, so that replays run on the same times of day.
+func (r *Recorder) Schedule(sc *Schedule) {
+	r.schedule = sc
+}
+
+// Game records the game that wraps the Transition, so that replays ramp Options up to the same levels.
+func (r *Recorder) Game(g *Game) {
+	r.game = g
+}
+
+// Tick records a state machine tick that rendered the state n.  The tick is timed at the recording of the tick.
+func (r *Recorder) Tick(n State) {
+	e := Event{Type: EventTick, State: &n}
+	if r.game != nil {
+		e.Level = r.game.Level()
+	}
+	r.record(e)
+}
+
+// Recording returns the recorded session.
+func (r *Recorder) Recording() *Recording {
+	rec := r.rec
+	rec.Start = r.start
+	rec.Events = append([]Event(nil), r.rec.Events...)
+	if r.schedule != nil {
+		sc := *r.schedule
+		sc.Clock = nil
+		rec.Schedule = &sc
+	}
+	if r.game != nil {
+		d := r.game.Difficulty
+		rec.Difficulty = &d
+	}
+	return &rec
+}
@@ -0,0 +1,174 @@
+package dummyneko
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordSession simulates a host session: the pointer runs away, the host changes options and obstacles, then the pointer rests.
+// sessionTicks is the length of the session, long enough for tiring neko to catch up.
+const sessionTicks = 70
+
+func recordSession() *Recording {
+	n, m, b := State{}, Pos{}, DefaultOptions
+
+	now := time.Unix(0, 0)
+	r := NewRecorder(n, m, b)
+	r.Now = func() time.Time { return now }
+	r.start = now
+
+	s := NewInitialState()
+	for i := 0; i < sessionTicks; i++ {
+		switch {
+		case i == 5:
+			m = Pos{X: 300, Y: 200}
+			r.Enter(m)
+		case i < 30:
+			m.X += 7
+			r.Move(m)
+		case i == 30:
+			b.Obstacles = []Rect{{Min: Pos{X: 400, Y: 0}, Max: Pos{X: 450, Y: 400}}}
+			r.Obstacles(b.Obstacles)
+		case i == 40:
+			b.StillTransition = 2
+			b.ScratchCount = 2
+			r.Options(b)
+		}
+		r.Obstacles(b.Obstacles)
+		r.Options(b)
+
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		r.Tick(n)
+		now = now.Add(b.TickDuration)
+	}
+	return r.Recording()
+}
+
+func TestRecording(t *testing.T) {
+	rec := recordSession()
+
+	var obstacles, options int
+	for _, e := range rec.Events {
+		switch e.Type {
+		case EventObstacles:
+			obstacles++
+		case EventOptions:
+			options++
+			if string(e.Options) != `{"ScratchCount":2,"StillTransition":2}` {
+				t.Errorf("unexpected options event %s", e.Options)
+			}
+		}
+	}
+	if obstacles != 1 || options != 1 {
+		t.Errorf("expected 1 obstacles and 1 options event, got %d and %d", obstacles, options)
+	}
+
+	var buf bytes.Buffer
+	if _, err := rec.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Verify(NewInitialState()); err != nil {
+		t.Error(err)
+	}
+
+	states := rec.Replay(NewInitialState())
+	if len(states) != sessionTicks {
+		t.Fatalf("expected %d states, got %d", sessionTicks, len(states))
+	}
+	if a := states[len(states)-1].Action; a.Kind() != KindItch && a.Kind() != KindScratch && a.Kind() != KindStill {
+		t.Errorf("expected neko to rest at the end, got %q", a)
+	}
+
+	for i := range rec.Events {
+		if rec.Events[i].Type == EventEnter {
+			rec.Events[i].Pos.Y += 100
+			break
+		}
+	}
+	if err := rec.Verify(NewInitialState()); err == nil {
+		t.Error("Verify of a tampered recording succeeded")
+	}
+}
+
+func TestReplayScheduleGame(t *testing.T) {
+	n, m, b := State{}, Pos{}, DefaultOptions
+	now := time.Date(2020, 8, 22, 21, 59, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	// The night period starts on tick 30, and neko is kept asleep from tick 60.
+	sc := DefaultSchedule
+	sc.Clock = ClockFunc(clock)
+	sc.Awake = &Hours{Start: At(6, 0), End: At(22, 1)}
+	g := NewGame(Difficulty{LevelTicks: 10, MaxLevel: 5, SpeedUp: 0.2, MinAlertTicks: 1}, 0)
+	g.Start()
+
+	r := NewRecorder(n, m, b)
+	r.Now = clock
+	r.start = now
+	r.Schedule(&sc)
+	r.Game(g)
+
+	s := g.Wrap(sc.Wrap(NewInitialState()))
+	for i := 0; i < 80; i++ {
+		if i%20 == 5 {
+			m = Pos{X: float64(100 + 10*i), Y: float64(200 - 2*i)}
+			r.Move(m)
+		}
+		r.Options(b)
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		r.Tick(n)
+		now = now.Add(2 * time.Second)
+	}
+	if StateName(s) != "sleep" {
+		t.Fatalf("expected neko to be kept asleep at the end, got %q", StateName(s))
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.Recording().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Verify(NewInitialState()); err != nil {
+		t.Error(err)
+	}
+
+	rec.Schedule, rec.Difficulty = nil, nil
+	if err := rec.Verify(NewInitialState()); err == nil {
+		t.Error("Verify without the schedule and the game succeeded")
+	}
+}
+
+func TestReplayTestdata(t *testing.T) {
+	f, err := os.Open("testdata/recording.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rec, err := ReadRecording(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Verify(NewInitialState()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadRecordingVersion(t *testing.T) {
+	_, err := ReadRecording(strings.NewReader(`{"version": 2}`))
+	if err == nil || err.Error() != "unsupported recording version 2" {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package dummyneko
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a Clock implemented by a function, e.g. time.Now.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// TimeOfDay is the time since midnight.  In JSON, it is encoded as a "15:04" string, and "24:00" is the end of a day.
+type TimeOfDay time.Duration
+
+// At returns the time of day at the given hour and minute.
+func At(hour, min int) TimeOfDay {
+	return TimeOfDay(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+}
+
+// timeOfDay returns the time of day of t in its location.
+func timeOfDay(t time.Time) TimeOfDay {
+	h, m, s := t.Clock()
+	return TimeOfDay(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second)
+}
+
+func (t TimeOfDay) String() string {
+	d := time.Duration(t)
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || h > 24 || m < 0 || m > 59 || h == 24 && m != 0 {
+		return fmt.Errorf("invalid time of day %q", s)
+	}
+	*t = At(h, m)
+	return nil
+}
+
+// Hours is a time span within a day.  Spans with End before Start wrap around midnight, e.g. 22:00 to 06:00.
+type Hours struct {
+	Start TimeOfDay `json:"start"`
+	End   TimeOfDay `json:"end"`
+}
+
+// contains reports whether the span contains the time of day.
+func (h Hours) contains(t TimeOfDay) bool {
+	if h.Start <= h.End {
+		return t >= h.Start && t < h.End
+	}
+	return t >= h.Start || t < h.End
+}
+
+// OptionsPatch changes some fields of Options.  In JSON, it is an object with the changed fields, like in EventOptions recordings.
+type OptionsPatch struct {
+	raw json.RawMessage
+	// fields are indexes of the changed fields in values.
+	fields []int
+	values Options
+}
+
+// ParsePatch decodes the JSON object of changed Options fields.  Unknown fields are an error.
+func ParsePatch(data []byte) (OptionsPatch, error) {
+	var p OptionsPatch
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p.values); err != nil {
+		return p, fmt.Errorf("invalid options: %v", err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return p, fmt.Errorf("invalid options: %v", err)
+	}
+	t := reflect.TypeOf(p.values)
+	for k := range keys {
+		f, ok := t.FieldByNameFunc(func(name string) bool {
+			return strings.EqualFold(name, k)
+		})
+		if !ok {
+			return p, fmt.Errorf("invalid options: ambiguous field %q", k)
+		}
+		p.fields = append(p.fields, f.Index[0])
+	}
+	p.raw = append(json.RawMessage(nil), data...)
+	return p, nil
+}
+
+// MustParsePatch is like ParsePatch but panics on errors.
+func MustParsePatch(data string) OptionsPatch {
+	p, err := ParsePatch([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Apply returns b with the fields changed.
+func (p OptionsPatch) Apply(b Options) Options {
+	dst, src := reflect.ValueOf(&b).Elem(), reflect.ValueOf(p.values)
+	for _, i := range p.fields {
+		dst.Field(i).Set(src.Field(i))
+	}
+	return b
+}
+
+func (p OptionsPatch) MarshalJSON() ([]byte, error) {
+	if p.raw == nil {
+		return []byte("{}"), nil
+	}
+	return p.raw, nil
+}
+
+func (p *OptionsPatch) UnmarshalJSON(data []byte) error {
+	v, err := ParsePatch(data)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Period changes Options during the hours.
+type Period struct {
+	Hours
+	Options OptionsPatch `json:"options"`
+}
+
+// Schedule varies the behavior of neko by the time of day.
+type Schedule struct {
+	// Clock defaults to the system clock.  Times of day are taken in the location of returned times.
+	Clock Clock `json:"-"`
+	// Periods change Options in turn, later ones take precedence.
+	Periods []Period `json:"periods,omitempty"`
+	// Awake, if set, keeps neko asleep outside the hours, ignoring the pointer.
+	Awake *Hours `json:"awake,omitempty"`
+	// SleepState is the state neko is kept in while asleep, defaults to "sleep".
+	SleepState string `json:"sleep_state,omitempty"`
+}
+
+// DefaultSchedule makes neko sleepier at night and scratch more in the morning.
+var DefaultSchedule = Schedule{
+	Periods: []Period{
+		{
+			Hours:   Hours{Start: At(22, 0), End: At(6, 0)},
+			Options: MustParsePatch(`{"StillTicks":2,"StillTransition":0}`),
+		},
+		{
+			Hours:   Hours{Start: At(6, 0), End: At(10, 0)},
+			Options: MustParsePatch(`{"StillTransition":2,"ScratchCount":8}`),
+		},
+	},
+}
+
+func (sc *Schedule) now() TimeOfDay {
+	if sc.Clock == nil {
+		return timeOfDay(time.Now())
+	}
+	return timeOfDay(sc.Clock.Now())
+}
+
+// Options returns b changed by the periods of the current time of day.
+func (sc *Schedule) Options(b Options) Options {
+	t := sc.now()
+	for _, p := range sc.Periods {
+		if p.contains(t) {
+			b = p.Options.Apply(b)
+		}
+	}
+	return b
+}
+
+// Asleep reports whether neko is kept asleep at the current time of day.
+func (sc *Schedule) Asleep() bool {
+	return sc.Awake != nil && !sc.Awake.contains(sc.now())
+}
+
+// Wrap returns a Transition that behaves like s with Options of the schedule.
+func (sc *Schedule) Wrap(s Transition) Transition {
+	return scheduleTransition{s, sc}
+}
+
+type scheduleTransition struct {
+	s  Transition
+	sc *Schedule
+}
+
+func (t scheduleTransition) Next(n State, m Pos, b Options) Transition {
+	sc := t.sc
+	b = sc.Options(b)
+	if !sc.Asleep() {
+		return scheduleTransition{t.s.Next(n, m, b), sc}
+	}
+
+	sleep := sc.SleepState
+	if sleep == "" {
+		sleep = "sleep"
+	}
+	if StateName(t.s) != sleep {
+		if s, err := Force(t.s, sleep, n, m, b); err == nil {
+			return scheduleTransition{untrack(s), sc}
+		}
+	}
+	// The pointer is ignored while asleep, and forgotten so that it does not seem to jump on waking.
+	return scheduleTransition{untrack(t.s.Next(n, Pos{n.X, n.Y}, b)), sc}
+}
+
+func (t scheduleTransition) Render(n State, m Pos, b Options) State {
+	return t.s.Render(n, m, t.sc.Options(b))
+}
+
+func (t scheduleTransition) unwrap() Transition {
+	return t.s
+}
+
+func (t scheduleTransition) rewrap(s Transition) Transition {
+	return scheduleTransition{s, t.sc}
+}
+
+// Animate implements Animator.
+func (t scheduleTransition) Animate(n State, m Pos, b Options) Animation {
+	return Animate(t.s, n, m, t.sc.Options(b))
+}
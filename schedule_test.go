@@ -0,0 +1,168 @@
+package dummyneko
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func clockAt(hour, min int) Clock {
+	return ClockFunc(func() time.Time {
+		return time.Date(2020, 8, 22, hour, min, 0, 0, time.UTC)
+	})
+}
+
+func TestHoursContains(t *testing.T) {
+	day := Hours{Start: At(9, 0), End: At(18, 0)}
+	night := Hours{Start: At(22, 0), End: At(6, 0)}
+	cases := []struct {
+		h Hours
+		t TimeOfDay
+		e bool
+	}{
+		{day, At(9, 0), true},
+		{day, At(12, 30), true},
+		{day, At(18, 0), false},
+		{day, At(3, 0), false},
+		{night, At(23, 0), true},
+		{night, At(0, 0), true},
+		{night, At(5, 59), true},
+		{night, At(6, 0), false},
+		{night, At(12, 0), false},
+	}
+	for _, c := range cases {
+		if v := c.h.contains(c.t); v != c.e {
+			t.Errorf("%v-%v contains %v: expected %v, got %v", c.h.Start, c.h.End, c.t, c.e, v)
+		}
+	}
+}
+
+func TestTimeOfDayJSON(t *testing.T) {
+	var h Hours
+	if err := json.Unmarshal([]byte(`{"start":"22:30","end":"06:05"}`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Start != At(22, 30) || h.End != At(6, 5) {
+		t.Errorf("expected 22:30-06:05, got %v-%v", h.Start, h.End)
+	}
+	data, err := json.Marshal(h)
+	if err != nil || string(data) != `{"start":"22:30","end":"06:05"}` {
+		t.Errorf("unexpected JSON %s (%v)", data, err)
+	}
+	var end TimeOfDay
+	if err := json.Unmarshal([]byte(`"24:00"`), &end); err != nil || end != At(24, 0) {
+		t.Errorf("expected 24:00 to be the end of a day, got %v (%v)", end, err)
+	}
+	for _, s := range []string{`"noon"`, `"25:00"`, `"24:30"`, `"12:60"`, `12`} {
+		var v TimeOfDay
+		if err := json.Unmarshal([]byte(s), &v); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
+
+func TestScheduleOptions(t *testing.T) {
+	sc := DefaultSchedule
+	b := DefaultOptions
+
+	cases := []struct {
+		hour            int
+		stillTicks      uint
+		stillTransition uint
+		scratchCount    uint
+	}{
+		{3, 2, 0, b.ScratchCount},
+		{23, 2, 0, b.ScratchCount},
+		{7, b.StillTicks, 2, 8},
+		{12, b.StillTicks, b.StillTransition, b.ScratchCount},
+	}
+	for _, c := range cases {
+		sc.Clock = clockAt(c.hour, 0)
+		o := sc.Options(b)
+		if o.StillTicks != c.stillTicks || o.StillTransition != c.stillTransition || o.ScratchCount != c.scratchCount {
+			t.Errorf("at %d:00: expected StillTicks %d, StillTransition %d, ScratchCount %d; got %d, %d, %d",
+				c.hour, c.stillTicks, c.stillTransition, c.scratchCount, o.StillTicks, o.StillTransition, o.ScratchCount)
+		}
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	p, err := ParsePatch([]byte(`{"StillTicks":2,"scratchCount":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := DefaultOptions
+	b.ScratchCount = 5
+	o := p.Apply(b)
+	if o.StillTicks != 2 || o.ScratchCount != 0 || o.Step != b.Step {
+		t.Errorf("unexpected patched options %+v", o)
+	}
+
+	for _, s := range []string{`{"Sleepy":true}`, `{"StillTicks":"2"}`, `[]`, `{`} {
+		if _, err := ParsePatch([]byte(s)); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+
+	var sc Schedule
+	err = json.Unmarshal([]byte(`{"periods":[{"start":"22:00","end":"06:00","options":{"StilTicks":2}}]}`), &sc)
+	if err == nil {
+		t.Error("expected error for a schedule with an unknown option")
+	}
+}
+
+func TestScheduleAsleep(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	hour := 20
+	sc := &Schedule{
+		Clock: ClockFunc(func() time.Time {
+			return time.Date(2020, 8, 22, hour, 0, 0, 0, time.UTC)
+		}),
+		Awake: &Hours{Start: At(9, 0), End: At(18, 0)},
+	}
+	s := sc.Wrap(NewInitialState())
+	n, m := State{}, Pos{X: 100}
+
+	for i := 0; i < 5; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		if StateName(s) != "sleep" || n.X != 0 {
+			t.Fatalf("tick %d: expected neko to sleep outside working hours, got %q at %v", i, StateName(s), n.X)
+		}
+	}
+
+	hour = 10
+	for i := 0; i < 5; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+	}
+	if StateName(s) != "run" {
+		t.Errorf("expected neko to chase during working hours, got %q", StateName(s))
+	}
+}
+
+func TestScheduleWake(t *testing.T) {
+	b := DefaultOptions
+	b.HitSize = 32
+	hour := 20
+	sc := &Schedule{
+		Clock: ClockFunc(func() time.Time {
+			return time.Date(2020, 8, 22, hour, 0, 0, 0, time.UTC)
+		}),
+		Awake: &Hours{Start: At(9, 0), End: At(18, 0)},
+	}
+	s := sc.Wrap(NewInitialState())
+	n, m := State{X: 100, Y: 100}, Pos{X: 130, Y: 130}
+
+	for i := 0; i < 5; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+	}
+
+	// The pointer rests over neko, it did not move while neko was asleep.
+	hour = 10
+	s = s.Next(n, m, b)
+	if name := StateName(s); name == "startled" {
+		t.Errorf("expected neko not to be startled on waking, got %q", name)
+	}
+}
@@ -0,0 +1,183 @@
+// Package server runs a shared neko simulation for many WebSocket clients.
+//
+// Clients send their pointer positions, and the server chases one of them and broadcasts the rendered State to everyone, so all clients see the same neko.
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	neko "github.com/tie/dummyneko"
+)
+
+// Message types.
+const (
+	// TypeHello is sent by the server to a new client with its ID.
+	TypeHello = "hello"
+	// TypeState is broadcast by the server on each tick.
+	TypeState = "state"
+	// TypePointer is sent by clients when their pointer moves.
+	TypePointer = "pointer"
+	// TypeLeave is sent by clients when their pointer leaves the page.
+	TypeLeave = "leave"
+)
+
+// Message is a JSON message exchanged over WebSocket.
+type Message struct {
+	Type string `json:"type"`
+	// ID of the client in hello messages.
+	ID string `json:"id,omitempty"`
+	// Pos of the pointer in pointer messages.
+	Pos *neko.Pos `json:"pos,omitempty"`
+	// State of the neko in state messages.
+	State *neko.State `json:"state,omitempty"`
+	// Target is the ID of the chased client in state messages.
+	Target string `json:"target,omitempty"`
+}
+
+// sendBuffer is the number of messages queued for a client.  Slow clients miss states rather than stall the simulation.
+const sendBuffer = 16
+
+type client struct {
+	id      string
+	pointer *neko.Pos
+	send    chan Message
+}
+
+// Server is an authoritative neko simulation.  It is an http.Handler accepting WebSocket connections.
+type Server struct {
+	mu      sync.Mutex
+	b       neko.Options
+	n       neko.State
+	chase   *neko.Chase
+	clients map[*client]bool
+	lastID  int
+	handler websocket.Handler
+}
+
+// New returns a Server with the given options and target selection strategy.
+func New(b neko.Options, strategy neko.Strategy) *Server {
+	s := &Server{
+		b:       b,
+		chase:   neko.NewChase(neko.NewInitialState(), strategy),
+		clients: make(map[*client]bool),
+	}
+	s.handler = websocket.Handler(s.serve)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// State returns the current neko state.
+func (s *Server) State() neko.State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// Run ticks the simulation until the context is done.
+func (s *Server) Run(ctx context.Context) error {
+	d := s.b.TickDuration
+	if d <= 0 {
+		d = neko.DefaultTickDuration
+	}
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.Tick()
+		}
+	}
+}
+
+// Tick advances the simulation by a single tick and broadcasts the new state.
+func (s *Server) Tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ts []neko.Target
+	for c := range s.clients {
+		if c.pointer != nil {
+			ts = append(ts, neko.Target{ID: c.id, Pos: *c.pointer})
+		}
+	}
+	// Map iteration order is random, keep the simulation deterministic.
+	sort.Slice(ts, func(i, j int) bool {
+		return ts[i].ID < ts[j].ID
+	})
+
+	var target string
+	s.n, target = s.chase.Tick(s.n, ts, s.b)
+	n := s.n
+	s.broadcast(Message{Type: TypeState, State: &n, Target: target})
+}
+
+func (s *Server) broadcast(msg Message) {
+	for c := range s.clients {
+		select {
+		case c.send <- msg:
+		default:
+		}
+	}
+}
+
+func (s *Server) serve(ws *websocket.Conn) {
+	defer ws.Close()
+
+	s.mu.Lock()
+	s.lastID++
+	c := &client{
+		id:   strconv.Itoa(s.lastID),
+		send: make(chan Message, sendBuffer),
+	}
+	s.clients[c] = true
+	n := s.n
+	c.send <- Message{Type: TypeHello, ID: c.id}
+	c.send <- Message{Type: TypeState, State: &n}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range c.send {
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				// Unblock the receiver below.
+				ws.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		var msg Message
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			break
+		}
+		s.mu.Lock()
+		switch msg.Type {
+		case TypePointer:
+			c.pointer = msg.Pos
+		case TypeLeave:
+			c.pointer = nil
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	delete(s.clients, c)
+	close(c.send)
+	s.mu.Unlock()
+	<-done
+}
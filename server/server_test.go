@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+
+	neko "github.com/tie/dummyneko"
+)
+
+func dial(t *testing.T, url string) (*websocket.Conn, string) {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(url, "http"), "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello := receive(t, ws)
+	if hello.Type != TypeHello || hello.ID == "" {
+		t.Fatalf("expected hello message, got %+v", hello)
+	}
+	if msg := receive(t, ws); msg.Type != TypeState {
+		t.Fatalf("expected initial state, got %+v", msg)
+	}
+	return ws, hello.ID
+}
+
+func receive(t *testing.T, ws *websocket.Conn) Message {
+	t.Helper()
+	var msg Message
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func send(t *testing.T, ws *websocket.Conn, msg Message) {
+	t.Helper()
+	if err := websocket.JSON.Send(ws, msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	b := neko.Options{Step: 10, Dmax: 5}
+	srv := New(b, neko.StrategyNearest)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	near, nearID := dial(t, ts.URL)
+	defer near.Close()
+	far, _ := dial(t, ts.URL)
+	defer far.Close()
+
+	send(t, near, Message{Type: TypePointer, Pos: &neko.Pos{X: 100}})
+	send(t, far, Message{Type: TypePointer, Pos: &neko.Pos{X: -500}})
+	// Pointer messages are handled asynchronously, so tick until neko starts running.
+	var states [2]Message
+	for i := 0; i < 100; i++ {
+		srv.Tick()
+		states[0], states[1] = receive(t, near), receive(t, far)
+		if states[0].State.Action.Kind() == neko.KindRun {
+			break
+		}
+	}
+
+	if *states[0].State != *states[1].State || states[0].Target != states[1].Target {
+		t.Errorf("clients received different states: %+v and %+v", states[0], states[1])
+	}
+	if states[0].Target != nearID {
+		t.Errorf("expected neko to chase the nearest client %q, got %q", nearID, states[0].Target)
+	}
+	if n := srv.State(); n.X <= 0 {
+		t.Errorf("expected neko to run toward the nearest client, got %+v", n)
+	}
+
+	send(t, near, Message{Type: TypeLeave})
+	near.Close()
+	for i := 0; i < 100; i++ {
+		srv.Tick()
+		if msg := receive(t, far); msg.Target != nearID && msg.Target != "" {
+			return
+		}
+	}
+	t.Errorf("neko kept chasing the client that left")
+}
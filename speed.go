@@ -0,0 +1,43 @@
+package dummyneko
+
+import (
+	"math"
+)
+
+// runSpeed returns the speed of Run state for the next tick given the current speed and the remaining distance d to the pointer.
+//
+// Without speed dynamics configured in Options, neko always runs at Step.
+func runSpeed(speed, d float64, b Options) float64 {
+	top := b.Step
+	if b.MaxSpeed > 0 {
+		top = b.MaxSpeed
+	}
+	if b.SprintSpeed > 0 && d > b.SprintDistance {
+		top = b.SprintSpeed
+	}
+
+	switch {
+	case speed < top && b.Acceleration > 0:
+		speed = math.Min(top, speed+b.Acceleration)
+	case speed > top && b.Deceleration > 0:
+		speed = math.Max(top, speed-b.Deceleration)
+	default:
+		speed = top
+	}
+
+	if b.Deceleration > 0 {
+		// Fastest speed that still allows to stop at the pointer.
+		speed = math.Min(speed, math.Sqrt(2*b.Deceleration*d))
+	}
+	return speed
+}
+
+// pathLength returns the length of the path from a through the waypoints.
+func pathLength(a Pos, path []Pos) float64 {
+	var d float64
+	for _, p := range path {
+		d += distance(a, p)
+		a = p
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package dummyneko
+
+import (
+	"testing"
+)
+
+func TestRunSpeed(t *testing.T) {
+	cases := []struct {
+		speed, d float64
+		b        Options
+		e        float64
+	}{
+		{ // constant speed
+			0, 100,
+			Options{Step: 10},
+			10,
+		},
+		{ // accelerate from rest
+			0, 100,
+			Options{Step: 10, Acceleration: 4},
+			4,
+		},
+		{ // accelerate up to MaxSpeed
+			8, 100,
+			Options{Step: 10, MaxSpeed: 12, Acceleration: 5},
+			12,
+		},
+		{ // sprint
+			12, 500,
+			Options{MaxSpeed: 12, SprintSpeed: 30, SprintDistance: 300, Acceleration: 5},
+			17,
+		},
+		{ // sprint over, no deceleration
+			30, 200,
+			Options{MaxSpeed: 12, SprintSpeed: 30, SprintDistance: 300},
+			12,
+		},
+		{ // sprint over, decelerate
+			30, 200,
+			Options{MaxSpeed: 12, SprintSpeed: 30, SprintDistance: 300, Deceleration: 5},
+			25,
+		},
+		{ // brake to stop at the pointer
+			12, 8,
+			Options{MaxSpeed: 12, Deceleration: 4},
+			8,
+		},
+	}
+
+	for _, c := range cases {
+		speed := runSpeed(c.speed, c.d, c.b)
+		if speed != c.e {
+			t.Errorf("runSpeed(%f, %f, %+v) expected %f, got %f", c.speed, c.d, c.b, c.e, speed)
+		}
+	}
+}
+
+func TestRunAcceleration(t *testing.T) {
+	b := Options{
+		Step:         10,
+		Acceleration: 2,
+		AlertTicks:   1,
+	}
+	m := Pos{X: 1000}
+
+	var n State
+	s := NewInitialState()
+	var xs []float64
+	for i := 0; i < 7; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		xs = append(xs, n.X)
+	}
+
+	// still, alert, then accelerate by 2 until Step is reached
+	e := []float64{0, 0, 2, 6, 12, 20, 30}
+	for i := range e {
+		if xs[i] != e[i] {
+			t.Fatalf("expected positions %v, got %v", e, xs)
+		}
+	}
+}
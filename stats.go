@@ -0,0 +1,101 @@
+package dummyneko
+
+// Stats are activity statistics of a neko.
+type Stats struct {
+	// Ticks spent in each behavior state, by StateName.
+	Ticks map[string]uint
+	// Distance run, in pixels.  Jumps of neko outside of running states, e.g. teleports by the host, do not count.
+	Distance float64
+	// Chases is the number of times neko started running.
+	Chases uint
+	// Catches is the number of chases that ended with the pointer nearby.
+	Catches uint
+	// LongestNap is the longest run of ticks showing sleep actions.
+	LongestNap uint
+	// Fatigue is the current fatigue, see Fatigue function.
+	Fatigue float64
+}
+
+// StatsCollector collects Stats of the Transitions it wraps.
+type StatsCollector struct {
+	stats Stats
+	nap   uint
+}
+
+// NewStatsCollector returns an empty StatsCollector.
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{
+		stats: Stats{Ticks: make(map[string]uint)},
+	}
+}
+
+// Wrap returns a Transition that behaves like s and reports to the collector.  The wrapped Transition expects a single Render call after each Next, as hosts do on each tick.
+func (c *StatsCollector) Wrap(s Transition) Transition {
+	return statsTransition{s, c}
+}
+
+// Stats returns a copy of the collected statistics.
+func (c *StatsCollector) Stats() Stats {
+	st := c.stats
+	st.Ticks = make(map[string]uint, len(c.stats.Ticks))
+	for k, v := range c.stats.Ticks {
+		st.Ticks[k] = v
+	}
+	return st
+}
+
+type statsTransition struct {
+	s Transition
+	c *StatsCollector
+}
+
+func (t statsTransition) Next(n State, m Pos, b Options) Transition {
+	next := t.s.Next(n, m, b)
+	st := &t.c.stats
+
+	st.Ticks[StateName(next)] += 1
+	st.Fatigue = Fatigue(next)
+	if Moving(next) && !Moving(t.s) {
+		st.Chases += 1
+	}
+	if caught(t.s, next, n, m, b) {
+		st.Catches += 1
+	}
+	return statsTransition{next, t.c}
+}
+
+func (t statsTransition) Render(n State, m Pos, b Options) State {
+	r := t.s.Render(n, m, b)
+	c := t.c
+
+	if Moving(t.s) {
+		c.stats.Distance += distance(Pos{n.X, n.Y}, Pos{r.X, r.Y})
+	}
+	if r.Action.Kind() == KindSleep {
+		c.nap += 1
+		if c.nap > c.stats.LongestNap {
+			c.stats.LongestNap = c.nap
+		}
+	} else {
+		c.nap = 0
+	}
+	return r
+}
+
+func (t statsTransition) unwrap() Transition {
+	return t.s
+}
+
+func (t statsTransition) rewrap(s Transition) Transition {
+	return statsTransition{s, t.c}
+}
+
+// caught reports whether the transition from prev to next ended a chase with the pointer nearby.
+func caught(prev, next Transition, n State, m Pos, b Options) bool {
+	return Moving(prev) && !Moving(next) && pointerNearby(n, m, b)
+}
+
+// Animate implements Animator.
+func (t statsTransition) Animate(n State, m Pos, b Options) Animation {
+	return Animate(t.s, n, m, b)
+}
@@ -0,0 +1,113 @@
+package dummyneko
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStatsCollector(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	c := NewStatsCollector()
+	s := c.Wrap(NewInitialState())
+	n, m := State{}, Pos{X: 100}
+
+	var names []string
+	for i := 0; i < 40; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+		names = append(names, StateName(s))
+	}
+
+	st := c.Stats()
+	if st.Chases != 1 || st.Catches != 1 {
+		t.Errorf("expected a single chase and catch, got %d and %d", st.Chases, st.Catches)
+	}
+	if st.Distance != 100 {
+		t.Errorf("expected distance 100, got %v", st.Distance)
+	}
+	var total uint
+	for _, v := range st.Ticks {
+		total += v
+	}
+	if total != 40 {
+		t.Errorf("expected 40 ticks, got %d: %v", total, st.Ticks)
+	}
+	if st.Ticks["run"] != 10 {
+		t.Errorf("expected 10 run ticks, got %d", st.Ticks["run"])
+	}
+	if st.LongestNap == 0 || st.LongestNap != st.Ticks["sleep"] {
+		t.Errorf("expected a single nap of %d ticks, got %d; states %v", st.Ticks["sleep"], st.LongestNap, names)
+	}
+
+	// The pointer moves away and wakes neko up.
+	m = Pos{X: 300}
+	for i := 0; i < 40; i++ {
+		s = s.Next(n, m, b)
+		n = s.Render(n, m, b)
+	}
+	st2 := c.Stats()
+	if st2.Chases != 2 || st2.Catches != 2 {
+		t.Errorf("expected two chases and catches, got %d and %d", st2.Chases, st2.Catches)
+	}
+	if st2.LongestNap < st.LongestNap {
+		t.Errorf("longest nap decreased from %d to %d", st.LongestNap, st2.LongestNap)
+	}
+	if st.Ticks["run"] != 10 {
+		t.Error("Stats returned a shared map")
+	}
+
+	data, err := json.Marshal(st2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Stats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Catches != st2.Catches || decoded.Ticks["run"] != st2.Ticks["run"] {
+		t.Errorf("JSON round trip: %s", data)
+	}
+}
+
+func TestStatsForce(t *testing.T) {
+	b := Options{Step: 10, Dmax: 5}
+	c := NewStatsCollector()
+	s := c.Wrap(NewInitialState())
+	n, m := State{}, Pos{X: 100}
+
+	s, err := Force(s, "run", n, m, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if StateName(s) != "run" || !Moving(s) {
+		t.Fatalf("expected run state, got %q", StateName(s))
+	}
+	s = s.Next(n, m, b)
+	s.Render(n, m, b)
+	if st := c.Stats(); st.Ticks["run"] != 1 || st.Distance != 10 {
+		t.Errorf("expected forced state to stay wrapped, got %+v", st)
+	}
+}
+
+// jump is a Transition that moves neko without running.
+type jump struct{}
+
+func (s jump) Next(n State, m Pos, b Options) Transition { return s }
+
+func (s jump) Render(n State, m Pos, b Options) State {
+	n.X += 50
+	return n
+}
+
+func TestStatsDistanceRunOnly(t *testing.T) {
+	c := NewStatsCollector()
+	s := c.Wrap(jump{})
+	var n State
+	for i := 0; i < 3; i++ {
+		s = s.Next(n, Pos{}, Options{})
+		n = s.Render(n, Pos{}, Options{})
+	}
+	if st := c.Stats(); st.Distance != 0 {
+		t.Errorf("expected jumps outside running states not to count, got distance %v", st.Distance)
+	}
+}